@@ -0,0 +1,66 @@
+package doublemap
+
+// SetMany stores the given pairs in the map, skipping pairs whose key or value is already bound to a different
+// value or key, including bindings made by earlier pairs of the same call. The skipped pairs are returned in
+// the order given. If the map is empty, its internal maps are allocated once with room for all pairs unless they
+// are already sized for that many, see WithCapacity.
+//
+// If the map has a conflict handler, see WithOnConflict, conflicting pairs for which it returns Replace are
+// stored, and when it returns Abort the pair and all remaining pairs are returned as conflicts.
+func (m *Map[K, V]) SetMany(pairs []Pair[K, V]) (conflicts []Pair[K, V]) {
+	if len(m.kv) == 0 && (m.kv == nil || len(pairs) > m.capacity) {
+		m.capacity = max(m.capacity, len(pairs))
+		m.kv = make(map[K]V, m.capacity)
+		m.vk = make(map[V]K, m.capacity)
+	}
+	for i, p := range pairs {
+		if res, conflicted := m.resolve(p.Key, p.Value); conflicted {
+			switch res {
+			case Replace:
+				m.bind(p.Key, p.Value)
+			case Abort:
+				return append(conflicts, pairs[i:]...)
+			default:
+				conflicts = append(conflicts, p)
+			}
+			continue
+		}
+		if m.conflict(p.Key, p.Value) != nil {
+			conflicts = append(conflicts, p)
+			continue
+		}
+		m.gen++
+		m.kv[p.Key] = p.Value
+		m.vk[p.Value] = p.Key
+	}
+	return conflicts
+}
+
+// RemoveMany removes the mappings for the given keys and returns the number of mappings actually removed.
+func (m *Map[K, V]) RemoveMany(keys ...K) int {
+	n := 0
+	for _, k := range keys {
+		if v, ok := m.kv[k]; ok {
+			m.gen++
+			delete(m.kv, k)
+			delete(m.vk, v)
+			n++
+		}
+	}
+	return n
+}
+
+// RemoveManyByValue removes the mappings for the given values and returns the number of mappings actually
+// removed.
+func (m *Map[K, V]) RemoveManyByValue(values ...V) int {
+	n := 0
+	for _, v := range values {
+		if k, ok := m.vk[v]; ok {
+			m.gen++
+			delete(m.kv, k)
+			delete(m.vk, v)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,155 @@
+package doublemap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+var (
+	// ErrInvalidFormat is returned when decoding data that is not a valid binary encoding of a map.
+	ErrInvalidFormat = errors.New("doublemap: invalid binary format")
+	// ErrUnsupportedVersion is returned when decoding data written in an unknown version of the binary format.
+	ErrUnsupportedVersion = errors.New("doublemap: unsupported binary format version")
+)
+
+const (
+	binaryMagic = "DMAP"
+	// BinaryVersion is the version of the binary format written by MarshalBinary. Version 2 added the schema
+	// version of the application to the header, see WithSchemaVersion; data written in version 1 is read as
+	// schema version 0.
+	BinaryVersion = 2
+)
+
+// appendHeader appends the header of the binary format for a map with the given schema version to buf.
+func appendHeader(buf []byte, schema uint64) []byte {
+	buf = append(append(buf, binaryMagic...), BinaryVersion)
+	return binary.AppendUvarint(buf, schema)
+}
+
+// parseHeader parses the header of data in the binary format and returns the schema version and the payload
+// following the header.
+func parseHeader(data []byte) (schema uint64, payload []byte, err error) {
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return 0, nil, fmt.Errorf("%w: missing header", ErrInvalidFormat)
+	}
+	payload = data[len(binaryMagic)+1:]
+	switch version := data[len(binaryMagic)]; version {
+	case 1:
+		return 0, payload, nil
+	case BinaryVersion:
+		schema, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return 0, nil, fmt.Errorf("%w: invalid schema version", ErrInvalidFormat)
+		}
+		return schema, payload[n:], nil
+	default:
+		return 0, nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+}
+
+// encodedEntry is a key-value pair together with the encoding of the key, used to sort entries.
+type encodedEntry[K, V comparable] struct {
+	key   K
+	value V
+	data  []byte
+}
+
+// encodedEntries encodes the entries of the map in deterministic order. If the map was created with
+// WithKeyOrder, that order is used. Otherwise, if the key type is an integer, floating point or string type,
+// the entries are sorted by key, and else by the encoding of their keys.
+func (m *Map[K, V]) encodedEntries() ([]encodedEntry[K, V], error) {
+	entries := make([]encodedEntry[K, V], 0, len(m.kv))
+	for k, v := range m.kv {
+		data, err := appendValue(nil, reflect.ValueOf(&k).Elem())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, encodedEntry[K, V]{key: k, value: v, data: data})
+	}
+	if m.keyOrder != nil {
+		sort.Slice(entries, func(i, j int) bool { return m.keyOrder(entries[i].key, entries[j].key) < 0 })
+	} else if ordered(reflect.TypeFor[K]()) {
+		sort.Slice(entries, func(i, j int) bool {
+			return compareOrdered(reflect.ValueOf(entries[i].key), reflect.ValueOf(entries[j].key)) < 0
+		})
+	} else {
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].data, entries[j].data) < 0 })
+	}
+	return entries, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The map is encoded in a compact, versioned format that
+// starts with a header identifying the format and its version, followed by the number of entries and the
+// entries themselves. The output is deterministic: maps with the same contents always have the same encoding,
+// so it can be hashed and compared. Keys and values must be booleans, numbers, strings, arrays or structs of
+// these, or implement encoding.BinaryMarshaler and encoding.BinaryUnmarshaler; otherwise an error wrapping
+// ErrUnsupportedType is returned.
+func (m *Map[K, V]) MarshalBinary() ([]byte, error) {
+	entries, err := m.encodedEntries()
+	if err != nil {
+		return nil, err
+	}
+	buf := appendHeader(nil, m.schemaVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(entries)))
+	for _, e := range entries {
+		buf = append(buf, e.data...)
+		if buf, err = appendValue(buf, reflect.ValueOf(&e.value).Elem()); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the contents of the map with the entries
+// decoded from data, which must have been written by MarshalBinary, and rebuilds the reverse index. Data
+// written with an older schema version than that of the map is upgraded with the migrations given by
+// WithMigration or RegisterMigration, see WithSchemaVersion.
+// Invalid data results in an error wrapping ErrInvalidFormat or ErrUnsupportedVersion, and duplicate keys or
+// values in an error wrapping ErrDuplicateKey or ErrDuplicateValue. On error the map is left unchanged.
+func (m *Map[K, V]) UnmarshalBinary(data []byte) error {
+	schema, payload, err := parseHeader(data)
+	if err != nil {
+		return err
+	}
+	if schema != m.schemaVersion {
+		if payload, err = m.migrate(schema, payload); err != nil {
+			return err
+		}
+	}
+	r := bytes.NewReader(payload)
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	kv := make(map[K]V, min(n, uint64(r.Len())))
+	for i := uint64(0); i < n; i++ {
+		var k K
+		var v V
+		if err := readValue(r, reflect.ValueOf(&k).Elem()); err != nil {
+			return decodeError(err)
+		}
+		if err := readValue(r, reflect.ValueOf(&v).Elem()); err != nil {
+			return decodeError(err)
+		}
+		if _, ok := kv[k]; ok {
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, k)
+		}
+		kv[k] = v
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, r.Len())
+	}
+	return m.assign(kv)
+}
+
+// decodeError wraps an unexpected end of input or other read error as ErrInvalidFormat.
+func decodeError(err error) error {
+	if errors.Is(err, ErrUnsupportedType) || errors.Is(err, ErrInvalidFormat) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+}
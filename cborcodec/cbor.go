@@ -0,0 +1,49 @@
+// Package cborcodec encodes and decodes doublemap.Map values in the CBOR format (RFC 8949). A map is written
+// as an ordinary CBOR map from keys to values, so the data stays readable by any CBOR tool, and the reverse
+// index is rebuilt when it is loaded again.
+package cborcodec
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/rasteric/doublemap"
+)
+
+var (
+	// encMode produces the deterministic core encoding, so equal maps always have equal encodings.
+	encMode, _ = cbor.CoreDetEncOptions().EncMode()
+	// decMode rejects CBOR maps with duplicate keys instead of keeping the last one.
+	decMode, _ = cbor.DecOptions{DupMapKey: cbor.DupMapKeyEnforcedAPF}.DecMode()
+)
+
+// Encode writes the key-value pairs of m to w as a single CBOR map, with keys sorted as required by the
+// deterministic core encoding.
+func Encode[K, V comparable](w io.Writer, m *doublemap.Map[K, V]) error {
+	return encMode.NewEncoder(w).Encode(m.ToMap())
+}
+
+// Decode reads a single CBOR map from r and returns it as a new double map. If the CBOR map contains duplicate
+// keys, an error is returned, and if it contains the same value for more than one key, an error wrapping
+// doublemap.ErrDuplicateValue is returned.
+func Decode[K, V comparable](r io.Reader) (*doublemap.Map[K, V], error) {
+	var kv map[K]V
+	if err := decMode.NewDecoder(r).Decode(&kv); err != nil {
+		return nil, err
+	}
+	return doublemap.FromMap(kv)
+}
+
+// Marshal returns the CBOR encoding of m, see Encode.
+func Marshal[K, V comparable](m *doublemap.Map[K, V]) ([]byte, error) {
+	return encMode.Marshal(m.ToMap())
+}
+
+// Unmarshal decodes data produced by Marshal into a new double map, see Decode.
+func Unmarshal[K, V comparable](data []byte) (*doublemap.Map[K, V], error) {
+	var kv map[K]V
+	if err := decMode.Unmarshal(data, &kv); err != nil {
+		return nil, err
+	}
+	return doublemap.FromMap(kv)
+}
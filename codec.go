@@ -0,0 +1,228 @@
+package doublemap
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+)
+
+// ErrUnsupportedType is returned by the binary encoding if a key or value type cannot be encoded.
+var ErrUnsupportedType = errors.New("doublemap: unsupported type for binary encoding")
+
+var (
+	binaryMarshalerType   = reflect.TypeFor[encoding.BinaryMarshaler]()
+	binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+)
+
+// appendValue appends a self-delimiting binary encoding of rv to buf. Booleans, integers, floating point and
+// complex numbers, strings, arrays and structs with exported fields of such types are supported, as well as
+// any type implementing encoding.BinaryMarshaler and encoding.BinaryUnmarshaler. The encoding of a value only
+// depends on the value itself, so equal values always have equal encodings.
+func appendValue(buf []byte, rv reflect.Value) ([]byte, error) {
+	t := rv.Type()
+	if t.Implements(binaryMarshalerType) && reflect.PointerTo(t).Implements(binaryUnmarshalerType) {
+		data, err := rv.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		return append(buf, data...), nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(buf, rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(buf, rv.Uint()), nil
+	case reflect.Float32:
+		return binary.BigEndian.AppendUint32(buf, math.Float32bits(float32(rv.Float()))), nil
+	case reflect.Float64:
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(rv.Float())), nil
+	case reflect.Complex64:
+		c := rv.Complex()
+		buf = binary.BigEndian.AppendUint32(buf, math.Float32bits(float32(real(c))))
+		return binary.BigEndian.AppendUint32(buf, math.Float32bits(float32(imag(c)))), nil
+	case reflect.Complex128:
+		c := rv.Complex()
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(real(c)))
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(imag(c))), nil
+	case reflect.String:
+		s := rv.String()
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		return append(buf, s...), nil
+	case reflect.Array:
+		var err error
+		for i := 0; i < rv.Len() && err == nil; i++ {
+			buf, err = appendValue(buf, rv.Index(i))
+		}
+		return buf, err
+	case reflect.Struct:
+		var err error
+		for i := 0; i < t.NumField() && err == nil; i++ {
+			if !t.Field(i).IsExported() {
+				return nil, fmt.Errorf("%w: %v has unexported fields", ErrUnsupportedType, t)
+			}
+			buf, err = appendValue(buf, rv.Field(i))
+		}
+		return buf, err
+	}
+	return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, t)
+}
+
+// A byteReader reads encoded values from a byte stream.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// readValue decodes a value encoded by appendValue from r into rv, which must be settable.
+func readValue(r byteReader, rv reflect.Value) error {
+	t := rv.Type()
+	if t.Implements(binaryMarshalerType) && reflect.PointerTo(t).Implements(binaryUnmarshalerType) {
+		data, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		return rv.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := binary.ReadVarint(r)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		rv.SetUint(u)
+	case reflect.Float32:
+		var u uint32
+		if err := binary.Read(r, binary.BigEndian, &u); err != nil {
+			return err
+		}
+		rv.SetFloat(float64(math.Float32frombits(u)))
+	case reflect.Float64:
+		var u uint64
+		if err := binary.Read(r, binary.BigEndian, &u); err != nil {
+			return err
+		}
+		rv.SetFloat(math.Float64frombits(u))
+	case reflect.Complex64:
+		var u [2]uint32
+		if err := binary.Read(r, binary.BigEndian, &u); err != nil {
+			return err
+		}
+		rv.SetComplex(complex(float64(math.Float32frombits(u[0])), float64(math.Float32frombits(u[1]))))
+	case reflect.Complex128:
+		var u [2]uint64
+		if err := binary.Read(r, binary.BigEndian, &u); err != nil {
+			return err
+		}
+		rv.SetComplex(complex(math.Float64frombits(u[0]), math.Float64frombits(u[1])))
+	case reflect.String:
+		data, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		rv.SetString(string(data))
+	case reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := readValue(r, rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				return fmt.Errorf("%w: %v has unexported fields", ErrUnsupportedType, t)
+			}
+			if err := readValue(r, rv.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %v", ErrUnsupportedType, t)
+	}
+	return nil
+}
+
+// maxBytesLen limits the length of a single encoded string or byte slice to guard against corrupt input.
+const maxBytesLen = 1 << 30
+
+// bytesChunk is the number of bytes readBytes allocates at a time when it cannot tell how much input remains, so
+// that a corrupt length does not allocate much more memory than the input actually holds.
+const bytesChunk = 1 << 16
+
+// readBytes reads a length-prefixed byte slice written by appendValue.
+func readBytes(r byteReader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxBytesLen {
+		return nil, fmt.Errorf("%w: length %d too large", ErrInvalidFormat, n)
+	}
+	if l, ok := r.(interface{ Len() int }); ok {
+		if n > uint64(l.Len()) {
+			return nil, fmt.Errorf("%w: length %d exceeds the remaining input", ErrInvalidFormat, n)
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	data := make([]byte, 0, min(n, bytesChunk))
+	for uint64(len(data)) < n {
+		chunk := int(min(n-uint64(len(data)), bytesChunk))
+		data = slices.Grow(data, chunk)
+		if _, err := io.ReadFull(r, data[len(data):len(data)+chunk]); err != nil {
+			return nil, err
+		}
+		data = data[:len(data)+chunk]
+	}
+	return data, nil
+}
+
+// ordered reports whether values of type t can be compared with compareOrdered.
+func ordered(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+// compareOrdered compares two values of an ordered type, see ordered, like cmp.Compare.
+func compareOrdered(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	}
+	return cmp.Compare(a.String(), b.String())
+}
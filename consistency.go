@@ -0,0 +1,84 @@
+package doublemap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInconsistent is returned by CheckConsistency if the forward and reverse indexes of a map do not mirror
+// each other.
+var ErrInconsistent = errors.New("doublemap: inconsistent indexes")
+
+// CheckConsistency verifies that the key-to-value and value-to-key indexes of the map are exact mirror images
+// of each other. If they are not, an error wrapping ErrInconsistent that describes the first orphaned or
+// mismatched entry found is returned. Indexes can drift apart when Set stores the same value for several keys,
+// see Map.
+func (m *Map[K, V]) CheckConsistency() error {
+	for k, v := range m.kv {
+		k2, ok := m.vk[v]
+		if !ok {
+			return fmt.Errorf("%w: value %v of key %v has no reverse entry", ErrInconsistent, v, k)
+		}
+		if k2 != k {
+			return fmt.Errorf("%w: value %v of key %v maps back to key %v", ErrInconsistent, v, k, k2)
+		}
+	}
+	for v, k := range m.vk {
+		v2, ok := m.kv[k]
+		if !ok {
+			return fmt.Errorf("%w: key %v of value %v has no forward entry", ErrInconsistent, k, v)
+		}
+		if v2 != v {
+			return fmt.Errorf("%w: key %v of value %v maps to value %v", ErrInconsistent, k, v, v2)
+		}
+	}
+	return nil
+}
+
+// A RepairPolicy determines which index of a map is trusted when Repair rebuilds the other one.
+type RepairPolicy int
+
+const (
+	// ForwardWins rebuilds the value-to-key index from the key-to-value index.
+	ForwardWins RepairPolicy = iota
+	// ReverseWins rebuilds the key-to-value index from the value-to-key index.
+	ReverseWins
+)
+
+// Repair makes the indexes of the map consistent again by rebuilding one from the other, as determined by the
+// policy, and returns the number of entries added to or removed from either index. Entries of the trusted
+// index that cannot be represented because their value (or key, with ReverseWins) is already taken by another
+// entry are removed as well. The result passes CheckConsistency.
+func (m *Map[K, V]) Repair(policy RepairPolicy) (fixed int) {
+	if policy == ReverseWins {
+		fixed = repairIndexes(m.vk, m.kv)
+	} else {
+		fixed = repairIndexes(m.kv, m.vk)
+	}
+	if fixed > 0 {
+		m.gen++
+	}
+	return fixed
+}
+
+// repairIndexes makes secondary the exact mirror of primary, removing entries of primary that collide.
+func repairIndexes[A, B comparable](primary map[A]B, secondary map[B]A) (fixed int) {
+	for b, a := range secondary {
+		if b2, ok := primary[a]; !ok || b2 != b {
+			delete(secondary, b)
+			fixed++
+		}
+	}
+	for a, b := range primary {
+		a2, ok := secondary[b]
+		switch {
+		case !ok:
+			secondary[b] = a
+			fixed++
+		case a2 != a:
+			delete(primary, a)
+			fixed++
+		}
+	}
+	return fixed
+}
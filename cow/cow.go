@@ -0,0 +1,181 @@
+// Package doublemap/cow provides a generic concurrent copy-on-write Map[K comparable, V comparable] with the
+// operations of doublemap/parallel, for tables that are read on every request but changed rarely. Readers
+// load an immutable pair of indexes through an atomic pointer and never lock. Writers copy the indexes,
+// modify the copy and publish it atomically, so every change costs time proportional to the size of the map.
+package cow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// tables is an immutable version of the contents of a map.
+type tables[K comparable, V comparable] struct {
+	kv map[K]V
+	vk map[V]K
+}
+
+// A Map is a copy-on-write double map. It is safe for concurrent use, and its zero value is an empty map ready
+// to use. Readers always observe a consistent version of the map.
+type Map[K comparable, V comparable] struct {
+	current atomic.Pointer[tables[K, V]]
+	mutex   sync.Mutex // serializes writers
+}
+
+// New creates a new copy-on-write double map.
+func New[K, V comparable]() *Map[K, V] {
+	return &Map[K, V]{}
+}
+
+// load returns the current version of the map, which must not be modified.
+func (m *Map[K, V]) load() *tables[K, V] {
+	if t := m.current.Load(); t != nil {
+		return t
+	}
+	return &tables[K, V]{}
+}
+
+// modify calls fn with a copy of the current version of the map and publishes the copy afterwards, unless fn
+// returns false.
+func (m *Map[K, V]) modify(fn func(t *tables[K, V]) bool) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	old := m.load()
+	t := &tables[K, V]{kv: make(map[K]V, len(old.kv)+1), vk: make(map[V]K, len(old.vk)+1)}
+	for k, v := range old.kv {
+		t.kv[k] = v
+	}
+	for v, k := range old.vk {
+		t.vk[v] = k
+	}
+	if !fn(t) {
+		return false
+	}
+	m.current.Store(t)
+	return true
+}
+
+// Get returns the value for the given key and true, the null value of the value type and false if no value
+// was stored for this key. Get does not lock the map.
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	value, ok := m.load().kv[key]
+	return value, ok
+}
+
+// ByValue returns the key for a given value and true, the key type's null value and false if no key was
+// stored for this value. ByValue does not lock the map.
+func (m *Map[K, V]) ByValue(value V) (K, bool) {
+	key, ok := m.load().vk[value]
+	return key, ok
+}
+
+// Set sets a value for the given key. The whole map is copied.
+func (m *Map[K, V]) Set(key K, value V) {
+	m.modify(func(t *tables[K, V]) bool {
+		t.kv[key] = value
+		t.vk[value] = key
+		return true
+	})
+}
+
+// Remove removes the key and value mapping based on the given key. True is returned if the mapping was removed,
+// false is returned when there was no mapping for the key in the first place. The whole map is copied if the
+// mapping exists.
+func (m *Map[K, V]) Remove(key K) bool {
+	if _, ok := m.Get(key); !ok {
+		return false
+	}
+	return m.modify(func(t *tables[K, V]) bool {
+		value, ok := t.kv[key]
+		if ok {
+			delete(t.kv, key)
+			delete(t.vk, value)
+		}
+		return ok
+	})
+}
+
+// RemoveByValue removes a given key-value mapping by the given value. True is returned if the mapping has been
+// removed, false is returned if there was no such value in the double map in the first place. The whole map is
+// copied if the mapping exists.
+func (m *Map[K, V]) RemoveByValue(value V) bool {
+	if _, ok := m.ByValue(value); !ok {
+		return false
+	}
+	return m.modify(func(t *tables[K, V]) bool {
+		key, ok := t.vk[value]
+		if ok {
+			delete(t.kv, key)
+			delete(t.vk, value)
+		}
+		return ok
+	})
+}
+
+// Len returns the number of key-value pairs in the map. Len does not lock the map.
+func (m *Map[K, V]) Len() int {
+	return len(m.load().kv)
+}
+
+// Walk traverses key-value pairs in the map and provides them to the given function in unspecified order
+// until the function returns false. Walk visits the version of the map current when it was called without
+// locking it, so fn may modify the map, but the changes are not observed by the walk.
+func (m *Map[K, V]) Walk(fn func(key K, value V) bool) {
+	for k, v := range m.load().kv {
+		if !fn(k, v) {
+			break
+		}
+	}
+}
+
+// Keys returns the keys in the map in unspecified order.
+func (m *Map[K, V]) Keys() []K {
+	t := m.load()
+	keys := make([]K, 0, len(t.kv))
+	for k := range t.kv {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns the values in the map in unspecified order.
+func (m *Map[K, V]) Values() []V {
+	t := m.load()
+	values := make([]V, 0, len(t.kv))
+	for _, v := range t.kv {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Copy creates a copy of the map. Since versions of the map are immutable, the copy shares the current version
+// with the original and takes constant time.
+func (m *Map[K, V]) Copy() *Map[K, V] {
+	m2 := New[K, V]()
+	if t := m.current.Load(); t != nil {
+		m2.current.Store(t)
+	}
+	return m2
+}
+
+// Clear clears the map, removing all key-value pairs in it.
+func (m *Map[K, V]) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.current.Store(nil)
+}
+
+// String returns the contents of the map in the form "{k1:v1, k2:v2, ...}". Entries are sorted by their
+// textual representation so that the output is deterministic.
+func (m *Map[K, V]) String() string {
+	t := m.load()
+	entries := make([]string, 0, len(t.kv))
+	for k, v := range t.kv {
+		entries = append(entries, fmt.Sprintf("%v:%v", k, v))
+	}
+	sort.Strings(entries)
+	return "{" + strings.Join(entries, ", ") + "}"
+}
@@ -0,0 +1,56 @@
+package doublemap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// WriteCSV writes the map to w in CSV format, one record per key-value pair. The fields of each record are
+// produced by conv. The records are written in unspecified order, unless the map was created with
+// WithSortedEncoding or WithKeyOrder.
+func (m *Map[K, V]) WriteCSV(w io.Writer, conv func(key K, value V) []string) error {
+	cw := csv.NewWriter(w)
+	for _, k := range m.encodingKeys() {
+		if err := cw.Write(conv(k, m.kv[k])); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads CSV records from r, converts each of them to a key-value pair with parse and replaces the
+// contents of the map with the pairs read. Records may have varying numbers of fields, it is up to parse to
+// validate them. Errors returned by parse are annotated with the line of the record. Duplicate keys or values
+// result in an error wrapping ErrDuplicateKey or ErrDuplicateValue. On error the map is left unchanged.
+func (m *Map[K, V]) ReadCSV(r io.Reader, parse func(record []string) (K, V, error)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	kv := make(map[K]V)
+	vk := make(map[V]K)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		k, v, err := parse(record)
+		if err != nil {
+			return fmt.Errorf("doublemap: line %d: %w", line, err)
+		}
+		if _, ok := kv[k]; ok {
+			return fmt.Errorf("%w: %v on line %d", ErrDuplicateKey, k, line)
+		}
+		if k2, ok := vk[v]; ok {
+			return fmt.Errorf("%w: %v on line %d is already stored for key %v", ErrDuplicateValue, v, line, k2)
+		}
+		kv[k] = v
+		vk[v] = k
+	}
+	m.replace(kv, vk)
+	return nil
+}
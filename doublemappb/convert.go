@@ -0,0 +1,172 @@
+// Package doublemappb provides a protobuf wire representation of doublemap.Map snapshots, as defined by the
+// Snapshot message in doublemap.proto, so services exchanging bidirectional ID tables over gRPC can share one
+// message type. The message types are generated by protoc-gen-go; ToProto and FromProto convert between them and
+// double maps. Snapshots are marshalled and unmarshalled with the proto package like any other message.
+package doublemappb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative doublemap.proto
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/rasteric/doublemap"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	binaryMarshalerType   = reflect.TypeFor[encoding.BinaryMarshaler]()
+	binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+)
+
+// ToProto converts m into a snapshot. Keys and values must have a boolean, integer, floating point, string or
+// byte array type, or implement encoding.BinaryMarshaler and encoding.BinaryUnmarshaler, in which case they
+// are stored as bytes. The entries are sorted by the wire encoding of their keys, so equal maps always result
+// in equal snapshots.
+func ToProto[K, V comparable](m *doublemap.Map[K, V]) (*Snapshot, error) {
+	type sortable struct {
+		entry *Entry
+		key   []byte
+	}
+	entries := make([]sortable, 0, m.Len())
+	var err error
+	m.Walk(func(k K, v V) bool {
+		e := &Entry{}
+		if e.Key, err = toScalar(reflect.ValueOf(&k).Elem()); err != nil {
+			return false
+		}
+		if e.Value, err = toScalar(reflect.ValueOf(&v).Elem()); err != nil {
+			return false
+		}
+		var key []byte
+		if key, err = (proto.MarshalOptions{Deterministic: true}).Marshal(e.Key); err != nil {
+			return false
+		}
+		entries = append(entries, sortable{entry: e, key: key})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+	s := &Snapshot{Entries: make([]*Entry, len(entries))}
+	for i, e := range entries {
+		s.Entries[i] = e.entry
+	}
+	return s, nil
+}
+
+// FromProto converts a snapshot into a new double map. An error is returned if a scalar does not fit the key or
+// value type, and an error wrapping doublemap.ErrDuplicateKey or doublemap.ErrDuplicateValue if a key or value
+// occurs more than once.
+func FromProto[K, V comparable](s *Snapshot) (*doublemap.Map[K, V], error) {
+	m := doublemap.NewWithCapacity[K, V](len(s.Entries))
+	for _, e := range s.Entries {
+		var k K
+		var v V
+		if err := fromScalar(e.GetKey(), reflect.ValueOf(&k).Elem()); err != nil {
+			return nil, err
+		}
+		if err := fromScalar(e.GetValue(), reflect.ValueOf(&v).Elem()); err != nil {
+			return nil, err
+		}
+		// TrySet accepts a repeated identical entry, so duplicates are checked before storing.
+		if m.Has(k) {
+			return nil, fmt.Errorf("%w: %v", doublemap.ErrDuplicateKey, k)
+		}
+		if m.HasValue(v) {
+			return nil, fmt.Errorf("%w: %v", doublemap.ErrDuplicateValue, v)
+		}
+		m.Set(k, v)
+	}
+	return m, nil
+}
+
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+func toScalar(rv reflect.Value) (*Scalar, error) {
+	t := rv.Type()
+	if t.Implements(binaryMarshalerType) && reflect.PointerTo(t).Implements(binaryUnmarshalerType) {
+		data, err := rv.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		return &Scalar{Kind: &Scalar_BytesValue{BytesValue: data}}, err
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Scalar{Kind: &Scalar_StringValue{StringValue: rv.String()}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Scalar{Kind: &Scalar_IntValue{IntValue: rv.Int()}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Scalar{Kind: &Scalar_UintValue{UintValue: rv.Uint()}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Scalar{Kind: &Scalar_DoubleValue{DoubleValue: rv.Float()}}, nil
+	case reflect.Bool:
+		return &Scalar{Kind: &Scalar_BoolValue{BoolValue: rv.Bool()}}, nil
+	}
+	if isByteArray(t) {
+		data := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(data), rv)
+		return &Scalar{Kind: &Scalar_BytesValue{BytesValue: data}}, nil
+	}
+	return nil, fmt.Errorf("doublemappb: unsupported type %v", t)
+}
+
+func fromScalar(s *Scalar, rv reflect.Value) error {
+	t := rv.Type()
+	mismatch := fmt.Errorf("doublemappb: cannot store %T scalar in %v", s.GetKind(), t)
+	if t.Implements(binaryMarshalerType) && reflect.PointerTo(t).Implements(binaryUnmarshalerType) {
+		kind, ok := s.GetKind().(*Scalar_BytesValue)
+		if !ok {
+			return mismatch
+		}
+		return rv.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(kind.BytesValue)
+	}
+	switch kind := s.GetKind().(type) {
+	case *Scalar_StringValue:
+		if t.Kind() == reflect.String {
+			rv.SetString(kind.StringValue)
+			return nil
+		}
+	case *Scalar_IntValue:
+		v := kind.IntValue
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.OverflowInt(v) {
+				return fmt.Errorf("doublemappb: %d overflows %v", v, t)
+			}
+			rv.SetInt(v)
+			return nil
+		}
+	case *Scalar_UintValue:
+		v := kind.UintValue
+		switch t.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if rv.OverflowUint(v) {
+				return fmt.Errorf("doublemappb: %d overflows %v", v, t)
+			}
+			rv.SetUint(v)
+			return nil
+		}
+	case *Scalar_DoubleValue:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			rv.SetFloat(kind.DoubleValue)
+			return nil
+		}
+	case *Scalar_BoolValue:
+		if t.Kind() == reflect.Bool {
+			rv.SetBool(kind.BoolValue)
+			return nil
+		}
+	case *Scalar_BytesValue:
+		if isByteArray(t) && rv.Len() == len(kind.BytesValue) {
+			reflect.Copy(rv, reflect.ValueOf(kind.BytesValue))
+			return nil
+		}
+	}
+	return mismatch
+}
@@ -0,0 +1,353 @@
+// Wire representation of a snapshot of a doublemap.Map, for exchanging bidirectional lookup tables between
+// services. Each entry binds a key to a value; within a snapshot, every key and every value occurs at most once.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: doublemap.proto
+
+package doublemappb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// A Scalar is a single key or value.
+type Scalar struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*Scalar_StringValue
+	//	*Scalar_IntValue
+	//	*Scalar_UintValue
+	//	*Scalar_DoubleValue
+	//	*Scalar_BoolValue
+	//	*Scalar_BytesValue
+	Kind          isScalar_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Scalar) Reset() {
+	*x = Scalar{}
+	mi := &file_doublemap_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Scalar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Scalar) ProtoMessage() {}
+
+func (x *Scalar) ProtoReflect() protoreflect.Message {
+	mi := &file_doublemap_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Scalar.ProtoReflect.Descriptor instead.
+func (*Scalar) Descriptor() ([]byte, []int) {
+	return file_doublemap_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Scalar) GetKind() isScalar_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *Scalar) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Scalar_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+func (x *Scalar) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Scalar_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *Scalar) GetUintValue() uint64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Scalar_UintValue); ok {
+			return x.UintValue
+		}
+	}
+	return 0
+}
+
+func (x *Scalar) GetDoubleValue() float64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Scalar_DoubleValue); ok {
+			return x.DoubleValue
+		}
+	}
+	return 0
+}
+
+func (x *Scalar) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*Scalar_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *Scalar) GetBytesValue() []byte {
+	if x != nil {
+		if x, ok := x.Kind.(*Scalar_BytesValue); ok {
+			return x.BytesValue
+		}
+	}
+	return nil
+}
+
+type isScalar_Kind interface {
+	isScalar_Kind()
+}
+
+type Scalar_StringValue struct {
+	StringValue string `protobuf:"bytes,1,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Scalar_IntValue struct {
+	IntValue int64 `protobuf:"zigzag64,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Scalar_UintValue struct {
+	UintValue uint64 `protobuf:"varint,3,opt,name=uint_value,json=uintValue,proto3,oneof"`
+}
+
+type Scalar_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type Scalar_BoolValue struct {
+	BoolValue bool `protobuf:"varint,5,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type Scalar_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,6,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+func (*Scalar_StringValue) isScalar_Kind() {}
+
+func (*Scalar_IntValue) isScalar_Kind() {}
+
+func (*Scalar_UintValue) isScalar_Kind() {}
+
+func (*Scalar_DoubleValue) isScalar_Kind() {}
+
+func (*Scalar_BoolValue) isScalar_Kind() {}
+
+func (*Scalar_BytesValue) isScalar_Kind() {}
+
+// An Entry is a single key-value binding.
+type Entry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           *Scalar                `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         *Scalar                `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	mi := &file_doublemap_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_doublemap_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_doublemap_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Entry) GetKey() *Scalar {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *Entry) GetValue() *Scalar {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// A Snapshot holds all entries of a map.
+type Snapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*Entry               `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_doublemap_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_doublemap_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_doublemap_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Snapshot) GetEntries() []*Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+var File_doublemap_proto protoreflect.FileDescriptor
+
+const file_doublemap_proto_rawDesc = "" +
+	"\n" +
+	"\x0fdoublemap.proto\x12\fdoublemap.v1\"\xde\x01\n" +
+	"\x06Scalar\x12#\n" +
+	"\fstring_value\x18\x01 \x01(\tH\x00R\vstringValue\x12\x1d\n" +
+	"\tint_value\x18\x02 \x01(\x12H\x00R\bintValue\x12\x1f\n" +
+	"\n" +
+	"uint_value\x18\x03 \x01(\x04H\x00R\tuintValue\x12#\n" +
+	"\fdouble_value\x18\x04 \x01(\x01H\x00R\vdoubleValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x05 \x01(\bH\x00R\tboolValue\x12!\n" +
+	"\vbytes_value\x18\x06 \x01(\fH\x00R\n" +
+	"bytesValueB\x06\n" +
+	"\x04kind\"[\n" +
+	"\x05Entry\x12&\n" +
+	"\x03key\x18\x01 \x01(\v2\x14.doublemap.v1.ScalarR\x03key\x12*\n" +
+	"\x05value\x18\x02 \x01(\v2\x14.doublemap.v1.ScalarR\x05value\"9\n" +
+	"\bSnapshot\x12-\n" +
+	"\aentries\x18\x01 \x03(\v2\x13.doublemap.v1.EntryR\aentriesB+Z)github.com/rasteric/doublemap/doublemappbb\x06proto3"
+
+var (
+	file_doublemap_proto_rawDescOnce sync.Once
+	file_doublemap_proto_rawDescData []byte
+)
+
+func file_doublemap_proto_rawDescGZIP() []byte {
+	file_doublemap_proto_rawDescOnce.Do(func() {
+		file_doublemap_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_doublemap_proto_rawDesc), len(file_doublemap_proto_rawDesc)))
+	})
+	return file_doublemap_proto_rawDescData
+}
+
+var file_doublemap_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_doublemap_proto_goTypes = []any{
+	(*Scalar)(nil),   // 0: doublemap.v1.Scalar
+	(*Entry)(nil),    // 1: doublemap.v1.Entry
+	(*Snapshot)(nil), // 2: doublemap.v1.Snapshot
+}
+var file_doublemap_proto_depIdxs = []int32{
+	0, // 0: doublemap.v1.Entry.key:type_name -> doublemap.v1.Scalar
+	0, // 1: doublemap.v1.Entry.value:type_name -> doublemap.v1.Scalar
+	1, // 2: doublemap.v1.Snapshot.entries:type_name -> doublemap.v1.Entry
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_doublemap_proto_init() }
+func file_doublemap_proto_init() {
+	if File_doublemap_proto != nil {
+		return
+	}
+	file_doublemap_proto_msgTypes[0].OneofWrappers = []any{
+		(*Scalar_StringValue)(nil),
+		(*Scalar_IntValue)(nil),
+		(*Scalar_UintValue)(nil),
+		(*Scalar_DoubleValue)(nil),
+		(*Scalar_BoolValue)(nil),
+		(*Scalar_BytesValue)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_doublemap_proto_rawDesc), len(file_doublemap_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_doublemap_proto_goTypes,
+		DependencyIndexes: file_doublemap_proto_depIdxs,
+		MessageInfos:      file_doublemap_proto_msgTypes,
+	}.Build()
+	File_doublemap_proto = out.File
+	file_doublemap_proto_goTypes = nil
+	file_doublemap_proto_depIdxs = nil
+}
@@ -0,0 +1,127 @@
+package doublemap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// A Format selects the encoding used by SaveFile and LoadFile.
+type Format int
+
+const (
+	// FormatBinary is the binary format of MarshalBinary.
+	FormatBinary Format = iota
+	// FormatJSON is the JSON encoding of MarshalJSON.
+	FormatJSON
+	// FormatGob is the gob encoding of GobEncode.
+	FormatGob
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatBinary:
+		return "binary"
+	case FormatJSON:
+		return "json"
+	case FormatGob:
+		return "gob"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// encode returns the encoding of the map in the given format.
+func (m *Map[K, V]) encode(format Format) ([]byte, error) {
+	switch format {
+	case FormatBinary:
+		return m.MarshalBinary()
+	case FormatJSON:
+		return m.MarshalJSON()
+	case FormatGob:
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(m)
+		return buf.Bytes(), err
+	}
+	return nil, fmt.Errorf("doublemap: unknown format %v", format)
+}
+
+// decode replaces the contents of the map with data in the given format.
+func (m *Map[K, V]) decode(data []byte, format Format) error {
+	switch format {
+	case FormatBinary:
+		return m.UnmarshalBinary(data)
+	case FormatJSON:
+		return m.UnmarshalJSON(data)
+	case FormatGob:
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(m)
+	}
+	return fmt.Errorf("doublemap: unknown format %v", format)
+}
+
+// SaveFile writes the map to the file at path in the given format. The file is replaced atomically: the data
+// is written to a temporary file in the same directory, which is synced to disk and then renamed to path, so
+// readers never see a partially written file. A replaced file keeps its permissions; a new file is created
+// with mode 0666 before the umask, like by os.WriteFile.
+func (m *Map[K, V]) SaveFile(path string, format Format) error {
+	data, err := m.encode(format)
+	if err != nil {
+		return err
+	}
+	perm, exists := fs.FileMode(0o666), false
+	if fi, err := os.Stat(path); err == nil {
+		perm, exists = fi.Mode().Perm(), true
+	}
+	f, err := createTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp", perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly after a successful rename
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if exists {
+		// The umask applied when creating the temporary file must not change the mode of the replaced file.
+		if err := f.Chmod(perm); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// createTemp creates a new file with the given permissions, subject to the umask, and a random name starting
+// with prefix in dir. Unlike os.CreateTemp, which always uses mode 0600, it lets SaveFile choose the mode.
+func createTemp(dir, prefix string, perm fs.FileMode) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, fs.ErrExist) || i == 10000 {
+			return f, err
+		}
+	}
+}
+
+// LoadFile replaces the contents of the map with the contents of the file at path, which must be in the given
+// format, for example a file written by SaveFile. On error the map is left unchanged.
+func (m *Map[K, V]) LoadFile(path string, format Format) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return m.decode(data, format)
+}
@@ -0,0 +1,107 @@
+package doublemap
+
+import "fmt"
+
+// Filter returns a new map containing only the key-value pairs for which pred returns true. The receiver is
+// not modified.
+func (m *Map[K, V]) Filter(pred func(key K, value V) bool) *Map[K, V] {
+	m2 := m.derive(0)
+	for k, v := range m.kv {
+		if pred(k, v) {
+			m2.kv[k] = v
+			m2.vk[v] = k
+		}
+	}
+	return m2
+}
+
+// Transform creates a new map by applying fn to every key-value pair of m. If fn maps two pairs to the same
+// key or the same value, the transformation is not a bijection and an error wrapping ErrDuplicateKey or
+// ErrDuplicateValue is returned instead of a map. The map m is not modified.
+func Transform[K1, V1, K2, V2 comparable](m *Map[K1, V1], fn func(K1, V1) (K2, V2)) (*Map[K2, V2], error) {
+	m2 := NewWithCapacity[K2, V2](len(m.kv))
+	for k, v := range m.kv {
+		k2, v2 := fn(k, v)
+		if _, ok := m2.kv[k2]; ok {
+			return nil, fmt.Errorf("%w: %v produced by %v", ErrDuplicateKey, k2, k)
+		}
+		if _, ok := m2.vk[v2]; ok {
+			return nil, fmt.Errorf("%w: %v produced by %v", ErrDuplicateValue, v2, k)
+		}
+		m2.kv[k2] = v2
+		m2.vk[v2] = k2
+	}
+	return m2, nil
+}
+
+// Partition splits the map in a single traversal into a new map of the key-value pairs for which pred returns
+// true and a new map of the remaining pairs. The receiver is not modified.
+func (m *Map[K, V]) Partition(pred func(key K, value V) bool) (matching, rest *Map[K, V]) {
+	matching, rest = m.derive(0), m.derive(0)
+	for k, v := range m.kv {
+		dst := rest
+		if pred(k, v) {
+			dst = matching
+		}
+		dst.kv[k] = v
+		dst.vk[v] = k
+	}
+	return matching, rest
+}
+
+// Any returns true if pred returns true for at least one key-value pair of the map. It stops at the first
+// match.
+func (m *Map[K, V]) Any(pred func(key K, value V) bool) bool {
+	for k, v := range m.kv {
+		if pred(k, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Every returns true if pred returns true for all key-value pairs of the map, which is the case for an empty
+// map. It stops at the first pair for which pred returns false.
+func (m *Map[K, V]) Every(pred func(key K, value V) bool) bool {
+	for k, v := range m.kv {
+		if !pred(k, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of key-value pairs of the map for which pred returns true.
+func (m *Map[K, V]) Count(pred func(key K, value V) bool) int {
+	n := 0
+	for k, v := range m.kv {
+		if pred(k, v) {
+			n++
+		}
+	}
+	return n
+}
+
+// Fold accumulates over all key-value pairs of m in unspecified order, starting with init and replacing the
+// accumulator with the result of fn for every pair. The final accumulator is returned.
+func Fold[K, V comparable, A any](m *Map[K, V], init A, fn func(acc A, key K, value V) A) A {
+	acc := init
+	for k, v := range m.kv {
+		acc = fn(acc, k, v)
+	}
+	return acc
+}
+
+// Find returns the first key-value pair found for which pred returns true, together with true. If there is no
+// such pair, the null values of the key and value types and false are returned. Since the map has no order,
+// the pair found is unspecified if several pairs match.
+func (m *Map[K, V]) Find(pred func(key K, value V) bool) (K, V, bool) {
+	for k, v := range m.kv {
+		if pred(k, v) {
+			return k, v, true
+		}
+	}
+	var k K
+	var v V
+	return k, v, false
+}
@@ -0,0 +1,57 @@
+package doublemap
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// String returns the contents of the map in the form "{k1:v1, k2:v2, ...}". Entries are sorted by their
+// textual representation so that the output is deterministic.
+func (m *Map[K, V]) String() string {
+	return m.StringN(-1)
+}
+
+// StringN works like String but renders at most n entries, followed by "..." if the map has more entries.
+// A negative n renders all entries.
+func (m *Map[K, V]) StringN(n int) string {
+	entries := make([]string, 0, len(m.kv))
+	for k, v := range m.kv {
+		entries = append(entries, fmt.Sprintf("%v:%v", k, v))
+	}
+	sort.Strings(entries)
+	if n >= 0 && n < len(entries) {
+		entries = append(entries[:n], "...")
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// LogValue implements slog.LogValuer. The map is logged as a group containing its number of entries. Use
+// LogEntries to log the entries as well.
+func (m *Map[K, V]) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("len", len(m.kv)))
+}
+
+// LogEntries returns a slog.LogValuer that logs the number of entries of the map together with a group of at
+// most limit of its entries, sorted like the output of String. A negative limit logs all entries.
+func (m *Map[K, V]) LogEntries(limit int) slog.LogValuer {
+	return logEntries[K, V]{m: m, limit: limit}
+}
+
+type logEntries[K, V comparable] struct {
+	m     *Map[K, V]
+	limit int
+}
+
+func (l logEntries[K, V]) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(l.m.kv))
+	for k, v := range l.m.kv {
+		attrs = append(attrs, slog.Any(fmt.Sprint(k), v))
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	if l.limit >= 0 && l.limit < len(attrs) {
+		attrs = attrs[:l.limit]
+	}
+	return slog.GroupValue(slog.Int("len", len(l.m.kv)), slog.Attr{Key: "entries", Value: slog.GroupValue(attrs...)})
+}
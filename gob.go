@@ -0,0 +1,50 @@
+package doublemap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// GobEncode implements gob.GobEncoder. Only the key-value pairs are encoded, the reverse index is rebuilt by
+// GobDecode. The pairs are written in unspecified order, unless the map was created with WithSortedEncoding or
+// WithKeyOrder.
+func (m *Map[K, V]) GobEncode() ([]byte, error) {
+	entries := make([]Pair[K, V], 0, len(m.kv))
+	for _, k := range m.encodingKeys() {
+		entries = append(entries, Pair[K, V]{Key: k, Value: m.kv[k]})
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder. It replaces the contents of the map with the decoded pairs and rebuilds
+// the reverse index. If the same key or value occurs more than once, an error wrapping ErrDuplicateKey or
+// ErrDuplicateValue is returned and the map is left unchanged.
+func (m *Map[K, V]) GobDecode(data []byte) error {
+	var entries []Pair[K, V]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entries); err != nil {
+		return err
+	}
+	kv, err := pairsToMap(entries)
+	if err != nil {
+		return err
+	}
+	return m.assign(kv)
+}
+
+// pairsToMap returns a map of the given pairs, or an error wrapping ErrDuplicateKey if a key occurs more than
+// once.
+func pairsToMap[K, V comparable](pairs []Pair[K, V]) (map[K]V, error) {
+	kv := make(map[K]V, len(pairs))
+	for _, p := range pairs {
+		if _, ok := kv[p.Key]; ok {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateKey, p.Key)
+		}
+		kv[p.Key] = p.Value
+	}
+	return kv, nil
+}
@@ -0,0 +1,81 @@
+package doublemap
+
+// Invert returns a new map with keys and values exchanged, i.e., the values of m are the keys of the new map.
+// The new map's indexes are filled directly from the existing reverse and forward indexes of m.
+func (m *Map[K, V]) Invert() *Map[V, K] {
+	m2 := NewWithCapacity[V, K](len(m.kv))
+	for v, k := range m.vk {
+		m2.kv[v] = k
+	}
+	for k, v := range m.kv {
+		m2.vk[k] = v
+	}
+	return m2
+}
+
+// An InverseView provides access to a Map with the roles of keys and values exchanged. It shares the storage of
+// the underlying map without copying, so changes made through the view are visible in the map and vice versa.
+type InverseView[K comparable, V comparable] struct {
+	m *Map[K, V]
+}
+
+// InverseView returns a view of the map in which values act as keys and keys act as values. For example,
+// Get on the view is ByValue on the map.
+func (m *Map[K, V]) InverseView() InverseView[K, V] {
+	return InverseView[K, V]{m: m}
+}
+
+// Map returns the underlying map of the view.
+func (iv InverseView[K, V]) Map() *Map[K, V] {
+	return iv.m
+}
+
+// Get returns the key for the given value and true, the null value of the key type and false if no key was
+// stored for this value.
+func (iv InverseView[K, V]) Get(value V) (K, bool) {
+	return iv.m.ByValue(value)
+}
+
+// Set sets the key for the given value.
+func (iv InverseView[K, V]) Set(value V, key K) {
+	iv.m.Set(key, value)
+}
+
+// Remove removes the mapping based on the given value. True is returned if the mapping was removed, false is
+// returned when there was no mapping for the value in the first place.
+func (iv InverseView[K, V]) Remove(value V) bool {
+	return iv.m.RemoveByValue(value)
+}
+
+// ByValue returns the value for the given key and true, the null value of the value type and false if no value
+// was stored for this key.
+func (iv InverseView[K, V]) ByValue(key K) (V, bool) {
+	return iv.m.Get(key)
+}
+
+// RemoveByValue removes the mapping based on the given key. True is returned if the mapping was removed, false
+// is returned when there was no mapping for the key in the first place.
+func (iv InverseView[K, V]) RemoveByValue(key K) bool {
+	return iv.m.Remove(key)
+}
+
+// Has returns true if the given value is stored in the underlying map, false otherwise.
+func (iv InverseView[K, V]) Has(value V) bool {
+	return iv.m.HasValue(value)
+}
+
+// HasValue returns true if the given key is stored in the underlying map, false otherwise.
+func (iv InverseView[K, V]) HasValue(key K) bool {
+	return iv.m.Has(key)
+}
+
+// Len returns the number of mappings in the underlying map.
+func (iv InverseView[K, V]) Len() int {
+	return iv.m.Len()
+}
+
+// Walk traverses value-key pairs of the underlying map and provides them to the given function in unspecified
+// order until the function returns false.
+func (iv InverseView[K, V]) Walk(fn func(value V, key K) bool) {
+	iv.m.WalkByValue(fn)
+}
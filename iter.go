@@ -0,0 +1,72 @@
+package doublemap
+
+import "iter"
+
+// All returns an iterator over the key-value pairs of the map in unspecified order, for use with range. The
+// loop body must not modify the map, otherwise the iterator panics with ErrModified.
+//
+//	for k, v := range m.All() {
+//	    ...
+//	}
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		gen := m.gen
+		for k, v := range m.kv {
+			if !yield(k, v) {
+				return
+			}
+			m.checkGen(gen)
+		}
+	}
+}
+
+// KeysSeq returns an iterator over the keys of the map in unspecified order. Unlike Keys, it does not
+// allocate a slice. Like All, the iterator panics with ErrModified if the loop body modifies the map.
+func (m *Map[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		gen := m.gen
+		for k := range m.kv {
+			if !yield(k) {
+				return
+			}
+			m.checkGen(gen)
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the map in unspecified order. Unlike Values, it does not
+// allocate a slice. Like All, the iterator panics with ErrModified if the loop body modifies the map.
+func (m *Map[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		gen := m.gen
+		for _, v := range m.kv {
+			if !yield(v) {
+				return
+			}
+			m.checkGen(gen)
+		}
+	}
+}
+
+// Insert stores the key-value pairs from seq in the map. A pair displaces any existing mapping with the same key
+// or the same value, so later pairs take precedence over earlier ones and the map stays consistent. In strict
+// mode or with a conflict handler, see WithOnConflict, the pairs are stored using Set instead, so conflicting
+// pairs are handled like by Set.
+func (m *Map[K, V]) Insert(seq iter.Seq2[K, V]) {
+	for k, v := range seq {
+		if m.strict || m.onConflict != nil {
+			m.Set(k, v)
+		} else {
+			m.bind(k, v)
+		}
+	}
+}
+
+// Collect creates a new double map from the key-value pairs of seq, for example Collect(maps.All(src)). Like
+// Insert, later pairs displace earlier ones with the same key or value. Since the order of maps.All is
+// unspecified, which key is kept for a value that occurs more than once in src is unspecified, too.
+func Collect[K, V comparable](seq iter.Seq2[K, V]) *Map[K, V] {
+	m := New[K, V]()
+	m.Insert(seq)
+	return m
+}
@@ -0,0 +1,40 @@
+package doublemap
+
+import "encoding/json"
+
+// MarshalJSON implements json.Marshaler. The map is encoded as an ordinary JSON object mapping keys to values,
+// so the key type must be a string, an integer or implement encoding.TextMarshaler. The object members are
+// sorted by their encoded keys, unless the map was created with WithSortedEncoding or WithKeyOrder, in which
+// case they are written in that key order.
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	if m.kv == nil {
+		return []byte("{}"), nil
+	}
+	if m.keyOrder == nil {
+		return json.Marshal(m.kv)
+	}
+	buf := []byte{'{'}
+	for i, k := range m.encodingKeys() {
+		// Encoding a single entry map lets encoding/json apply its rules for object keys.
+		member, err := json.Marshal(map[K]V{k: m.kv[k]})
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, member[1:len(member)-1]...)
+	}
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the contents of the map with the decoded JSON object
+// and rebuilds the reverse index. If the object contains the same value for more than one key, an error
+// wrapping ErrDuplicateValue is returned and the map is left unchanged.
+func (m *Map[K, V]) UnmarshalJSON(data []byte) error {
+	var kv map[K]V
+	if err := json.Unmarshal(data, &kv); err != nil {
+		return err
+	}
+	return m.assign(kv)
+}
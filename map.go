@@ -1,53 +1,281 @@
 // Package doublemap provides a generic Map[K comparable, V comparable] with operations for getting and setting
 // values by key, and the corresponding reverse map operation of getting and setting keys by values. The Map is
-// not thread-safe.
+// not thread-safe. The zero value of Map is an empty map ready to use, and all methods have pointer receivers,
+// so a Map should always be passed around by pointer.
 //
 // Quickstart:
 //
-//     package main
-//     
-//     import (
-//       "fmt"
-//    
-//       "github.com/rasteric/doublemap"
-//     )
-//     
-//     func main() {
-//         m := doublemap.New[string, int]()
-//         m.Set("first", 1)
-//         m.Set("second", 2)
-//         m.Set("third", 3)
-//         v, _ := m.Get("first")
-//         fmt.Println(v)
-//         k, _ := m.ByValue(3)
-//         fmt.Println(k)
-//     }
+//	package main
+//
+//	import (
+//	  "fmt"
+//
+//	  "github.com/rasteric/doublemap"
+//	)
+//
+//	func main() {
+//	    m := doublemap.New[string, int]()
+//	    m.Set("first", 1)
+//	    m.Set("second", 2)
+//	    m.Set("third", 3)
+//	    v, _ := m.Get("first")
+//	    fmt.Println(v)
+//	    k, _ := m.ByValue(3)
+//	    fmt.Println(k)
+//	}
 package doublemap
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrDuplicateValue is returned when the same value would be stored for more than one key.
+	ErrDuplicateValue = errors.New("doublemap: duplicate value")
+	// ErrDuplicateKey is returned when more than one value would be stored for the same key.
+	ErrDuplicateKey = errors.New("doublemap: duplicate key")
+	// ErrKeyExists is returned when a key is already bound to a different value.
+	ErrKeyExists = errors.New("doublemap: key already exists")
+	// ErrValueExists is returned when a value is already bound to a different key.
+	ErrValueExists = errors.New("doublemap: value already exists")
+)
+
 // A Map stores keys and values in a way that makes reverse mapping from values to keys efficient at the
 // cost of additional memory and storage complexity. You should only use this map if your values are unique
 // - otherwise the value-related lookup functions make no sense and Remove might have unexpected results!
-type Map[K comparable,V comparable] struct {
-	kv map[K]V
-	vk map[V]K
+//
+// The zero value is an empty map ready to use. A Map must not be copied by value after first use, since the
+// copy would share its internal storage with the original.
+type Map[K comparable, V comparable] struct {
+	kv         map[K]V
+	vk         map[V]K
+	strict     bool
+	onConflict func(existingKey K, existingValue V, newKey K, newValue V) Resolution
+	keyOrder   func(a, b K) int
+	capacity   int
+	gen        uint64 // incremented on every modification, see checkGen
+
+	keyOverwrites, valueOverwrites uint64
+
+	schemaVersion uint64               // see WithSchemaVersion
+	migrations    map[uint64]Migration // see WithMigration
+}
+
+// A Pair is a single key-value binding of a Map.
+type Pair[K comparable, V comparable] struct {
+	Key   K
+	Value V
 }
 
-// New creates a new double map.
-func New[K, V comparable]() *Map[K, V] {
-	return &Map[K, V]{ kv: make(map[K]V), vk: make(map[V]K) }
+// New creates a new double map configured by the given options.
+func New[K, V comparable](opts ...Option) *Map[K, V] {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	m := &Map[K, V]{kv: make(map[K]V, c.capacity), vk: make(map[V]K, c.capacity), strict: c.strict,
+		capacity: c.capacity, schemaVersion: c.schemaVersion, migrations: c.migrations}
+	for from, fn := range c.migrations {
+		if fn == nil {
+			panic(fmt.Sprintf("doublemap: nil migration from schema version %d", from))
+		}
+		if from >= c.schemaVersion {
+			panic(fmt.Sprintf("doublemap: migration from schema version %d, map has schema version %d", from,
+				c.schemaVersion))
+		}
+	}
+	if c.onConflict != nil {
+		fn, ok := c.onConflict.(func(K, V, K, V) Resolution)
+		if !ok {
+			panic("doublemap: conflict handler does not match the key and value types of the map")
+		}
+		m.onConflict = fn
+	}
+	if c.sorted {
+		if !ordered(reflect.TypeFor[K]()) {
+			panic("doublemap: sorted encoding requires an ordered key type")
+		}
+		m.keyOrder = func(a, b K) int { return compareOrdered(reflect.ValueOf(a), reflect.ValueOf(b)) }
+	}
+	if c.keyOrder != nil {
+		fn, ok := c.keyOrder.(func(K, K) int)
+		if !ok {
+			panic("doublemap: key order does not match the key type of the map")
+		}
+		m.keyOrder = fn
+	}
+	return m
+}
+
+// NewWithCapacity creates a new double map whose internal maps are allocated with room for the given number
+// of entries. Use this for bulk loads of many entries to avoid repeated rehashing. It is a shorthand for
+// New(WithCapacity(capacity)).
+func NewWithCapacity[K, V comparable](capacity int) *Map[K, V] {
+	return New[K, V](WithCapacity(capacity))
+}
+
+// NewStrict creates a new double map in strict mode. A strict map maintains a one-to-one mapping between keys
+// and values: Set refuses to store a value that is already bound to a different key, and setting a new value
+// for an existing key removes the reverse entry of the previous value. It is a shorthand for
+// New(WithStrict()).
+func NewStrict[K, V comparable]() *Map[K, V] {
+	return New[K, V](WithStrict())
+}
+
+// derive creates a new empty map with the same configuration as m and room for the given number of entries.
+func (m *Map[K, V]) derive(capacity int) *Map[K, V] {
+	return &Map[K, V]{kv: make(map[K]V, capacity), vk: make(map[V]K, capacity), strict: m.strict,
+		onConflict: m.onConflict, keyOrder: m.keyOrder, capacity: capacity, schemaVersion: m.schemaVersion,
+		migrations: m.migrations}
+}
+
+// FromMap creates a new double map containing the key-value pairs of the given map. Since the values of a
+// double map must be unique, an error wrapping ErrDuplicateValue is returned if src contains the same value
+// for more than one key.
+func FromMap[K, V comparable](src map[K]V) (*Map[K, V], error) {
+	m := NewWithCapacity[K, V](len(src))
+	for k, v := range src {
+		if k2, ok := m.vk[v]; ok {
+			return nil, fmt.Errorf("%w: %v is stored for keys %v and %v", ErrDuplicateValue, v, k2, k)
+		}
+		m.kv[k] = v
+		m.vk[v] = k
+	}
+	return m, nil
+}
+
+// assign replaces the contents of the map with kv, which the map takes ownership of, after building the reverse
+// index for it. If kv contains the same value for more than one key, an error wrapping ErrDuplicateValue is
+// returned and the map is left unchanged.
+func (m *Map[K, V]) assign(kv map[K]V) error {
+	if kv == nil {
+		kv = make(map[K]V)
+	}
+	vk := make(map[V]K, len(kv))
+	for k, v := range kv {
+		if k2, ok := vk[v]; ok {
+			return fmt.Errorf("%w: %v is stored for keys %v and %v", ErrDuplicateValue, v, k2, k)
+		}
+		vk[v] = k
+	}
+	m.replace(kv, vk)
+	return nil
+}
+
+// replace replaces the contents of the map with the given indexes, which must mirror each other.
+func (m *Map[K, V]) replace(kv map[K]V, vk map[V]K) {
+	m.kv, m.vk = kv, vk
+	m.capacity = len(kv)
+	m.gen++
+}
+
+// FromPairs creates a new double map from the given key-value pairs. The pairs are stored in order, and a pair
+// displaces any earlier pair with the same key or the same value, so later pairs take precedence and the map
+// contains exactly one mapping for every key and value.
+func FromPairs[K, V comparable](pairs ...Pair[K, V]) *Map[K, V] {
+	m := NewWithCapacity[K, V](len(pairs))
+	for _, p := range pairs {
+		m.bind(p.Key, p.Value)
+	}
+	return m
 }
 
 // Get returns the value for the given key and true, the null value of the value type and false if no value
 // was stored for this key.
 func (m *Map[K, V]) Get(key K) (V, bool) {
-  value, ok := m.kv[key]
+	value, ok := m.kv[key]
 	return value, ok
 }
 
-// Set sets a value for the given key. 
+// Strict returns true if the map was created in strict mode, false otherwise.
+func (m *Map[K, V]) Strict() bool {
+	return m.strict
+}
+
+// init allocates the internal maps of a zero value Map.
+func (m *Map[K, V]) init() {
+	if m.kv == nil {
+		m.kv = make(map[K]V)
+		m.vk = make(map[V]K)
+	}
+}
+
+// Set sets a value for the given key. In strict mode, Set does nothing if the value is already bound to a
+// different key; use TrySet to find out whether the value was stored. If the map has a conflict handler, see
+// WithOnConflict, it decides whether a conflicting pair replaces the existing bindings.
 func (m *Map[K, V]) Set(key K, value V) {
+	m.store(key, value)
+}
+
+// store implements Set. If the conflict handler refuses the pair, store returns an error wrapping ErrKeyExists
+// or ErrValueExists, and in strict mode an error wrapping ErrValueExists if the value is already bound to a
+// different key. In both cases the map is left unchanged.
+func (m *Map[K, V]) store(key K, value V) error {
+	m.init()
+	if res, conflicted := m.resolve(key, value); conflicted {
+		if res != Replace {
+			return m.conflict(key, value)
+		}
+		m.bind(key, value)
+		return nil
+	}
+	if m.strict {
+		if k, ok := m.vk[value]; ok && k != key {
+			return fmt.Errorf("%w: %v is bound to %v", ErrValueExists, value, k)
+		}
+		if old, ok := m.kv[key]; ok {
+			m.unlinkValue(key, old)
+		}
+	}
+	m.countOverwrites(key, value)
+	m.gen++
+	m.kv[key] = value
+	m.vk[value] = key
+	return nil
+}
+
+// conflict returns an error wrapping ErrKeyExists or ErrValueExists if storing the given pair would conflict
+// with an existing mapping, nil otherwise.
+func (m *Map[K, V]) conflict(key K, value V) error {
+	if v, ok := m.kv[key]; ok && v != value {
+		return fmt.Errorf("%w: %v is bound to %v", ErrKeyExists, key, v)
+	}
+	if k, ok := m.vk[value]; ok && k != key {
+		return fmt.Errorf("%w: %v is bound to %v", ErrValueExists, value, k)
+	}
+	return nil
+}
+
+// TrySet sets a value for the given key only if neither is bound to something else. An error wrapping
+// ErrKeyExists is returned if the key is bound to a different value, and an error wrapping ErrValueExists if
+// the value is bound to a different key. In both cases the map is left unchanged.
+func (m *Map[K, V]) TrySet(key K, value V) error {
+	if err := m.conflict(key, value); err != nil {
+		return err
+	}
+	m.init()
+	m.gen++
+	m.kv[key] = value
+	m.vk[value] = key
+	return nil
+}
+
+// SetIfAbsent stores the pair only if neither the key nor the value is present in the map, and returns true if
+// the pair was stored.
+func (m *Map[K, V]) SetIfAbsent(key K, value V) bool {
+	if _, ok := m.kv[key]; ok {
+		return false
+	}
+	if _, ok := m.vk[value]; ok {
+		return false
+	}
+	m.init()
+	m.gen++
 	m.kv[key] = value
 	m.vk[value] = key
+	return true
 }
 
 // Remove removes the key and value mapping based on the given key. True is returned if the mapping was removed,
@@ -55,6 +283,7 @@ func (m *Map[K, V]) Set(key K, value V) {
 func (m *Map[K, V]) Remove(key K) bool {
 	value, ok := m.Get(key)
 	if ok {
+		m.gen++
 		delete(m.kv, key)
 		delete(m.vk, value)
 		return true
@@ -71,9 +300,10 @@ func (m *Map[K, V]) ByValue(value V) (K, bool) {
 
 // RemoveByValue removes a given key-value mapping by the given value. True is returned if the mapping has been
 // removed, false is returned if there was no such value in the double map in the first place.
-func (m Map[K, V]) RemoveByValue(value V) bool {
+func (m *Map[K, V]) RemoveByValue(value V) bool {
 	key, ok := m.ByValue(value)
 	if ok {
+		m.gen++
 		delete(m.kv, key)
 		delete(m.vk, value)
 		return true
@@ -84,30 +314,271 @@ func (m Map[K, V]) RemoveByValue(value V) bool {
 // Copy creates a copy of the key-value mapping. This operation is fairly slow but faster than using Get and Set
 // manually. The copy is not deep, i.e., any key and values are just copied using ordinary assignment.
 func (m *Map[K, V]) Copy() *Map[K, V] {
-	m2 := Map[K, V]{}
+	m2 := m.derive(len(m.kv))
 	for k, v := range m.kv {
 		m2.kv[k] = v
-		m2.vk[v] = k 
+		m2.vk[v] = k
 	}
-	return &m2
+	return m2
+}
+
+// DeepCopy creates a copy of the key-value mapping in which every key is duplicated with cloneK and every value
+// with cloneV. Either function may be nil, in which case keys or values are copied using ordinary assignment,
+// like in Copy.
+func (m *Map[K, V]) DeepCopy(cloneK func(K) K, cloneV func(V) V) *Map[K, V] {
+	m2 := m.derive(len(m.kv))
+	for k, v := range m.kv {
+		if cloneK != nil {
+			k = cloneK(k)
+		}
+		if cloneV != nil {
+			v = cloneV(v)
+		}
+		m2.kv[k] = v
+		m2.vk[v] = k
+	}
+	return m2
 }
 
 // Walk traverses key-value pairs in the map and provides them to the given function in unspecified order
-// until the function returns false. 
-func (m *Map[K, V]) Walk(fn func (key K, value V) bool) {
+// until the function returns false. The function must not modify the map, otherwise Walk panics with
+// ErrModified; use WalkMutable to change the map during a traversal.
+func (m *Map[K, V]) Walk(fn func(key K, value V) bool) {
+	gen := m.gen
 	for k, v := range m.kv {
 		if !fn(k, v) {
 			break
 		}
-  }
+		m.checkGen(gen)
+	}
 }
 
-// Clear clears the map, removing all key-valie pairs in it.
+// Clear clears the map, removing all key-value pairs in it. The internal maps keep their allocated memory,
+// so a cleared map can be refilled without reallocating.
 func (m *Map[K, V]) Clear() {
+	if len(m.kv) > 0 || len(m.vk) > 0 {
+		m.gen++
+	}
 	for k := range m.kv { // better than one loop since this is optimized by compiler
 		delete(m.kv, k)
 	}
-	for k:= range m.vk {
+	for k := range m.vk {
 		delete(m.vk, k)
 	}
 }
+
+// Len returns the number of key-value pairs in the map. This is an O(1) operation.
+func (m *Map[K, V]) Len() int {
+	return len(m.kv)
+}
+
+// Keys returns a newly allocated slice containing all keys of the map in unspecified order.
+func (m *Map[K, V]) Keys() []K {
+	keys := make([]K, 0, len(m.kv))
+	for k := range m.kv {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns a newly allocated slice containing all values of the map in unspecified order.
+func (m *Map[K, V]) Values() []V {
+	values := make([]V, 0, len(m.kv))
+	for _, v := range m.kv {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Has returns true if a value is stored for the given key, false otherwise. This is a single map lookup
+// and does not allocate.
+func (m *Map[K, V]) Has(key K) bool {
+	_, ok := m.kv[key]
+	return ok
+}
+
+// HasValue returns true if the given value is stored in the map, false otherwise. Like Has, this is a single
+// map lookup in the reverse index.
+func (m *Map[K, V]) HasValue(value V) bool {
+	_, ok := m.vk[value]
+	return ok
+}
+
+// ToMap returns a newly allocated Go map containing the key-value pairs of the double map.
+func (m *Map[K, V]) ToMap() map[K]V {
+	kv := make(map[K]V, len(m.kv))
+	for k, v := range m.kv {
+		kv[k] = v
+	}
+	return kv
+}
+
+// ToReverseMap returns a newly allocated Go map from the values of the double map to their keys.
+func (m *Map[K, V]) ToReverseMap() map[V]K {
+	vk := make(map[V]K, len(m.vk))
+	for v, k := range m.vk {
+		vk[v] = k
+	}
+	return vk
+}
+
+// GetOrSet returns the existing value for the given key and true if the key is present. Otherwise, it stores
+// the given value for the key and returns it together with false. If the map refuses to store the value, in
+// strict mode or because of the conflict handler, the null value of the value type and false are returned; use
+// TryGetOrSet to find out why.
+func (m *Map[K, V]) GetOrSet(key K, value V) (V, bool) {
+	v, ok, _ := m.TryGetOrSet(key, value)
+	return v, ok
+}
+
+// TryGetOrSet is like GetOrSet but reports a refused value. If the value cannot be stored because the map is
+// in strict mode and the value is bound to a different key, or because the conflict handler keeps the existing
+// bindings, an error wrapping ErrKeyExists or ErrValueExists is returned together with the null value of the
+// value type and false, and the map is left unchanged.
+func (m *Map[K, V]) TryGetOrSet(key K, value V) (V, bool, error) {
+	if v, ok := m.kv[key]; ok {
+		return v, true, nil
+	}
+	if err := m.store(key, value); err != nil {
+		var zero V
+		return zero, false, err
+	}
+	return value, false, nil
+}
+
+// GetOrCompute returns the existing value for the given key and true if the key is present. Otherwise, it calls
+// fn to construct a value, stores it for the key and returns it together with false. The function fn is only
+// called when the key is missing. If the computed value is refused, GetOrCompute behaves like GetOrSet; use
+// TryGetOrCompute to find out why.
+func (m *Map[K, V]) GetOrCompute(key K, fn func() V) (V, bool) {
+	v, ok, _ := m.TryGetOrCompute(key, fn)
+	return v, ok
+}
+
+// TryGetOrCompute is like GetOrCompute but reports a refused value like TryGetOrSet.
+func (m *Map[K, V]) TryGetOrCompute(key K, fn func() V) (V, bool, error) {
+	if v, ok := m.kv[key]; ok {
+		return v, true, nil
+	}
+	value := fn()
+	if err := m.store(key, value); err != nil {
+		var zero V
+		return zero, false, err
+	}
+	return value, false, nil
+}
+
+// unlinkValue removes the reverse entry for value if it still points to key.
+func (m *Map[K, V]) unlinkValue(key K, value V) {
+	if k, ok := m.vk[value]; ok && k == key {
+		m.gen++
+		delete(m.vk, value)
+	}
+}
+
+// Swap sets the value for the given key and returns the previous value and true, or the null value of the
+// value type and false if there was no value for the key. Unlike Set, Swap also removes the reverse entry of
+// the previous value, so it can no longer be found by ByValue. If the map refuses to store the value, in strict
+// mode or because of the conflict handler, see WithOnConflict, the map is left unchanged; use TrySwap to find
+// out whether the value was stored.
+func (m *Map[K, V]) Swap(key K, value V) (old V, existed bool) {
+	old, existed, _ = m.TrySwap(key, value)
+	return old, existed
+}
+
+// TrySwap is like Swap but reports a refused value. If the value cannot be stored because the map is in strict
+// mode and the value is bound to a different key, TrySwap returns an error wrapping ErrValueExists. A conflict
+// handler is consulted before anything is changed; if it does not return Replace, TrySwap returns an error
+// wrapping ErrKeyExists or ErrValueExists. In both cases the map is left unchanged.
+func (m *Map[K, V]) TrySwap(key K, value V) (old V, existed bool, err error) {
+	old, existed = m.kv[key]
+	if err := m.store(key, value); err != nil {
+		return old, existed, err
+	}
+	if existed && old != value {
+		m.unlinkValue(key, old)
+	}
+	return old, existed, nil
+}
+
+// Pop removes the key and value mapping based on the given key and returns the removed value and true, or the
+// null value of the value type and false if there was no mapping for the key.
+func (m *Map[K, V]) Pop(key K) (V, bool) {
+	value, ok := m.kv[key]
+	if ok {
+		m.gen++
+		delete(m.kv, key)
+		delete(m.vk, value)
+	}
+	return value, ok
+}
+
+// PopByValue removes the key and value mapping based on the given value and returns the removed key and true,
+// or the null value of the key type and false if there was no mapping for the value.
+func (m *Map[K, V]) PopByValue(value V) (K, bool) {
+	key, ok := m.vk[value]
+	if ok {
+		m.gen++
+		delete(m.kv, key)
+		delete(m.vk, value)
+	}
+	return key, ok
+}
+
+// bind stores the mapping from key to value and removes any mapping that would contradict it, i.e., the
+// reverse entry of the previous value of key and the forward entry of a different key previously bound to
+// value. After bind the map contains exactly one mapping for key and exactly one for value.
+func (m *Map[K, V]) bind(key K, value V) {
+	m.init()
+	m.countOverwrites(key, value)
+	m.gen++
+	if old, ok := m.kv[key]; ok {
+		m.unlinkValue(key, old)
+	}
+	if k, ok := m.vk[value]; ok && k != key {
+		delete(m.kv, k)
+	}
+	m.kv[key] = value
+	m.vk[value] = key
+}
+
+// Update sets the value for the given key to the result of fn, which is called with the current value and
+// true, or the null value of the value type and false if there is no value for the key. Both indexes are
+// updated together: the previous value can no longer be found by ByValue, and if the new value was bound to
+// a different key, that key's mapping is removed. In strict mode, the map is left unchanged instead if the new
+// value is bound to a different key.
+func (m *Map[K, V]) Update(key K, fn func(old V, exists bool) V) {
+	old, exists := m.kv[key]
+	value := fn(old, exists)
+	if m.strict {
+		if k, ok := m.vk[value]; ok && k != key {
+			return
+		}
+	}
+	m.bind(key, value)
+}
+
+// Entries returns a newly allocated slice containing all key-value pairs of the map in unspecified order.
+func (m *Map[K, V]) Entries() []Pair[K, V] {
+	entries := make([]Pair[K, V], 0, len(m.kv))
+	for k, v := range m.kv {
+		entries = append(entries, Pair[K, V]{Key: k, Value: v})
+	}
+	return entries
+}
+
+// GetDefault returns the value for the given key, or def if no value was stored for this key.
+func (m *Map[K, V]) GetDefault(key K, def V) V {
+	if v, ok := m.kv[key]; ok {
+		return v
+	}
+	return def
+}
+
+// ByValueDefault returns the key for the given value, or def if no key was stored for this value.
+func (m *Map[K, V]) ByValueDefault(value V, def K) K {
+	if k, ok := m.vk[value]; ok {
+		return k
+	}
+	return def
+}
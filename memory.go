@@ -0,0 +1,92 @@
+package doublemap
+
+import "unsafe"
+
+// rebuild replaces both internal maps with new maps allocated for the given number of entries and copies the
+// current entries into them.
+func (m *Map[K, V]) rebuild(capacity int) {
+	kv := make(map[K]V, capacity)
+	vk := make(map[V]K, capacity)
+	for k, v := range m.kv {
+		kv[k] = v
+	}
+	for v, k := range m.vk {
+		vk[v] = k
+	}
+	m.kv, m.vk = kv, vk
+	m.gen++
+	m.capacity = capacity
+}
+
+// Grow makes room for n additional entries, so that bulk inserts afterwards do not trigger repeated rehashing.
+// Since Go maps cannot be resized in place, Grow copies the current entries into newly allocated maps, which
+// is cheapest right after construction. Grow does nothing if n is not positive or if the internal maps were
+// already sized for the current entries and n more, e.g. by WithCapacity.
+func (m *Map[K, V]) Grow(n int) {
+	if n <= 0 || len(m.kv)+n <= m.capacity {
+		return
+	}
+	m.rebuild(len(m.kv) + n)
+}
+
+// Compact reclaims the memory held by the map after many entries have been removed. Go maps never shrink, so
+// Compact copies the current entries into newly allocated maps of the current size and lets the old ones be
+// garbage collected. This takes time proportional to the number of entries.
+func (m *Map[K, V]) Compact() {
+	if m.kv == nil {
+		return
+	}
+	m.rebuild(len(m.kv))
+}
+
+// Stats describes the size and usage of a Map, see Map.Stats.
+type Stats struct {
+	// Len is the number of entries.
+	Len int
+	// Capacity is the number of entries the internal maps were last sized for, or Len if that is larger.
+	Capacity int
+	// ForwardBytes and ReverseBytes estimate the memory used by the key-to-value and value-to-key indexes,
+	// including slot and control overhead. Memory referenced by keys and values, like the contents of
+	// strings, is not included.
+	ForwardBytes int
+	ReverseBytes int
+	// KeyOverwrites counts how often a stored key was set to a different value.
+	KeyOverwrites uint64
+	// ValueOverwrites counts how often a stored value was bound to a different key.
+	ValueOverwrites uint64
+}
+
+// countOverwrites updates the overwrite counters for storing the given pair.
+func (m *Map[K, V]) countOverwrites(key K, value V) {
+	if v, ok := m.kv[key]; ok && v != value {
+		m.keyOverwrites++
+	}
+	if k, ok := m.vk[value]; ok && k != key {
+		m.valueOverwrites++
+	}
+}
+
+// estimateMapBytes approximates the memory of a Go map with the given number of entries and entry size. Maps
+// store entries in groups of 8 slots with an 8 byte control word and are grown at a load factor of 7/8.
+func estimateMapBytes(entries int, entrySize uintptr) int {
+	const groupSlots = 8
+	slots := (entries*8/7 + groupSlots - 1) / groupSlots * groupSlots
+	return slots*int(entrySize) + slots/groupSlots*8
+}
+
+// Stats returns the current size and usage statistics of the map. The memory figures are estimates based on
+// the shallow sizes of the key and value types.
+func (m *Map[K, V]) Stats() Stats {
+	var k K
+	var v V
+	entrySize := unsafe.Sizeof(k) + unsafe.Sizeof(v)
+	capacity := max(m.capacity, len(m.kv))
+	return Stats{
+		Len:             len(m.kv),
+		Capacity:        capacity,
+		ForwardBytes:    estimateMapBytes(capacity, entrySize),
+		ReverseBytes:    estimateMapBytes(max(m.capacity, len(m.vk)), entrySize),
+		KeyOverwrites:   m.keyOverwrites,
+		ValueOverwrites: m.valueOverwrites,
+	}
+}
@@ -0,0 +1,38 @@
+package doublemap
+
+// A MergePolicy determines how Merge handles a key-value pair whose key or value is already bound to something
+// else in the destination map.
+type MergePolicy int
+
+const (
+	// KeepExisting keeps the existing mappings and skips the conflicting pair.
+	KeepExisting MergePolicy = iota
+	// Overwrite removes the existing mappings that conflict with the pair and stores the pair.
+	Overwrite
+	// ErrorOnConflict makes Merge fail without modifying the destination map.
+	ErrorOnConflict
+)
+
+// Merge stores all key-value pairs of other in the map. The policy determines what happens with pairs whose key
+// or value is already bound to a different value or key in the map. With ErrorOnConflict, the first conflict
+// found is returned as an error wrapping ErrKeyExists or ErrValueExists and the map is left unchanged. The
+// other map is not modified.
+func (m *Map[K, V]) Merge(other *Map[K, V], policy MergePolicy) error {
+	if other == m {
+		return nil
+	}
+	if policy == ErrorOnConflict {
+		for k, v := range other.kv {
+			if err := m.conflict(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	for k, v := range other.kv {
+		if policy == KeepExisting && m.conflict(k, v) != nil {
+			continue
+		}
+		m.bind(k, v)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package doublemap
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A Migration upgrades the payload of a binary encoding from one schema version of the application to the
+// next. The payload is everything following the header, i.e. the number of entries and the entries
+// themselves. A migration must return the payload in the layout of the next schema version or an error.
+type Migration func(payload []byte) ([]byte, error)
+
+var (
+	migrationsMutex sync.RWMutex
+	migrations      = make(map[uint64]Migration)
+)
+
+// RegisterMigration registers a migration for all maps that upgrades binary encodings of the given schema
+// version to schema version from+1, typically from an init function of the package that owns the data. A
+// migration given to a map by WithMigration takes precedence over a registered one for the same version.
+// RegisterMigration panics if fn is nil or if a migration from the same version has already been registered.
+func RegisterMigration(from uint64, fn Migration) {
+	if fn == nil {
+		panic("doublemap: nil migration")
+	}
+	migrationsMutex.Lock()
+	defer migrationsMutex.Unlock()
+	if _, ok := migrations[from]; ok {
+		panic(fmt.Sprintf("doublemap: migration from schema version %d registered twice", from))
+	}
+	migrations[from] = fn
+}
+
+// WithSchemaVersion sets the schema version of the application data stored in the map. It is written into the
+// header of the binary encoding by MarshalBinary and Encoder, so that UnmarshalBinary can recognize data
+// written with an older schema, e.g. before the key or value type gained a field, and upgrade it with the
+// migrations given by WithMigration or RegisterMigration. Maps created without this option have schema
+// version 0.
+func WithSchemaVersion(version uint64) Option {
+	return func(c *config) {
+		c.schemaVersion = version
+	}
+}
+
+// WithMigration registers a migration that upgrades binary encodings of the given schema version to schema
+// version from+1. When UnmarshalBinary, and therefore LoadFile, encounters data written with an older schema
+// version than that of the map, it applies the migrations in sequence until the data has the schema version of
+// the map. WithMigration may be given several times for different versions; New panics if fn is nil or if
+// from is not older than the schema version of the map.
+func WithMigration(from uint64, fn Migration) Option {
+	return func(c *config) {
+		if c.migrations == nil {
+			c.migrations = make(map[uint64]Migration)
+		}
+		c.migrations[from] = fn
+	}
+}
+
+// migrate upgrades a payload written with the given schema version to the schema version of the map, using the
+// migrations of the map and falling back to those registered by RegisterMigration. It
+// returns an error wrapping ErrUnsupportedVersion if the schema version is newer than that of the map or if a
+// migration on the way is missing.
+func (m *Map[K, V]) migrate(schema uint64, payload []byte) ([]byte, error) {
+	if schema > m.schemaVersion {
+		return nil, fmt.Errorf("%w: schema version %d is newer than %d", ErrUnsupportedVersion, schema,
+			m.schemaVersion)
+	}
+	for ; schema < m.schemaVersion; schema++ {
+		fn, ok := m.migrations[schema]
+		if !ok {
+			migrationsMutex.RLock()
+			fn, ok = migrations[schema]
+			migrationsMutex.RUnlock()
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: no migration from schema version %d", ErrUnsupportedVersion, schema)
+		}
+		var err error
+		if payload, err = fn(payload); err != nil {
+			return nil, fmt.Errorf("doublemap: migrating from schema version %d: %w", schema, err)
+		}
+	}
+	return payload, nil
+}
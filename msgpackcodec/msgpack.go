@@ -0,0 +1,99 @@
+// Package msgpackcodec integrates doublemap.Map with the MessagePack encoding of github.com/vmihailenco/msgpack.
+// The adapter type Map wraps a *doublemap.Map and implements msgpack.CustomEncoder and msgpack.CustomDecoder,
+// so it can be embedded in structs that are stored in Redis or passed through msgpack-based RPC. A map is
+// encoded as an ordinary msgpack map with sorted keys, and the reverse index is rebuilt on decode.
+package msgpackcodec
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/rasteric/doublemap"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Map is an adapter that makes the wrapped double map encodable with msgpack. A nil wrapped map is encoded as
+// an empty msgpack map. The double map is deliberately not embedded, since its promoted MarshalBinary and
+// UnmarshalBinary methods would take precedence over the msgpack ones.
+type Map[K comparable, V comparable] struct {
+	Map *doublemap.Map[K, V]
+}
+
+// Wrap returns an adapter for m.
+func Wrap[K, V comparable](m *doublemap.Map[K, V]) Map[K, V] {
+	return Map[K, V]{Map: m}
+}
+
+// EncodeMsgpack implements msgpack.CustomEncoder. The entries are sorted by the msgpack encoding of their
+// keys, so equal maps always have equal encodings.
+func (a Map[K, V]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	var entries []entry[V]
+	if a.Map != nil {
+		entries = make([]entry[V], 0, a.Map.Len())
+		var err error
+		a.Map.Walk(func(k K, v V) bool {
+			var key []byte
+			if key, err = msgpack.Marshal(k); err != nil {
+				return false
+			}
+			entries = append(entries, entry[V]{key: key, value: v})
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+	if err := enc.EncodeMapLen(len(entries)); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := enc.Encode(msgpack.RawMessage(e.key)); err != nil {
+			return err
+		}
+		if err := enc.Encode(e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entry is a value together with the msgpack encoding of its key.
+type entry[V any] struct {
+	key   []byte
+	value V
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder. If the adapter wraps a map, its contents are replaced by the
+// decoded entries, otherwise a new map is allocated. If the msgpack map contains the same value for more than
+// one key, an error wrapping doublemap.ErrDuplicateValue is returned and the wrapped map is left unchanged.
+func (a *Map[K, V]) DecodeMsgpack(dec *msgpack.Decoder) error {
+	var kv map[K]V
+	if err := dec.Decode(&kv); err != nil {
+		return err
+	}
+	m, err := doublemap.FromMap(kv)
+	if err != nil {
+		return err
+	}
+	if a.Map == nil {
+		a.Map = m
+		return nil
+	}
+	a.Map.Clear()
+	return a.Map.Merge(m, doublemap.Overwrite)
+}
+
+// Marshal returns the msgpack encoding of m.
+func Marshal[K, V comparable](m *doublemap.Map[K, V]) ([]byte, error) {
+	return msgpack.Marshal(Wrap(m))
+}
+
+// Unmarshal decodes msgpack data produced by Marshal into a new double map.
+func Unmarshal[K, V comparable](data []byte) (*doublemap.Map[K, V], error) {
+	var a Map[K, V]
+	if err := msgpack.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	return a.Map, nil
+}
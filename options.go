@@ -0,0 +1,101 @@
+package doublemap
+
+import "slices"
+
+// An Option configures a Map created by New.
+type Option func(*config)
+
+type config struct {
+	capacity   int
+	strict     bool
+	onConflict any
+	sorted     bool
+	keyOrder   any
+
+	schemaVersion uint64
+	migrations    map[uint64]Migration
+}
+
+// WithCapacity allocates the internal maps with room for the given number of entries.
+func WithCapacity(capacity int) Option {
+	return func(c *config) {
+		c.capacity = capacity
+	}
+}
+
+// WithStrict creates the map in strict mode, see NewStrict.
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithSortedEncoding makes all encoders of the map write its entries in ascending key order, so that maps with
+// identical contents have byte-identical encodings, as needed for signing, caching and diffing. The key type
+// must be an integer, floating point or string type, otherwise New panics; use WithKeyOrder for other types.
+func WithSortedEncoding() Option {
+	return func(c *config) {
+		c.sorted = true
+	}
+}
+
+// WithKeyOrder makes all encoders of the map write its entries in the key order defined by cmp, which returns a
+// negative number, zero or a positive number like cmp.Compare. The key type of cmp must match that of the map
+// passed to New, otherwise New panics.
+func WithKeyOrder[K comparable](cmp func(a, b K) int) Option {
+	return func(c *config) {
+		c.keyOrder = cmp
+	}
+}
+
+// A Resolution is returned by a conflict handler to decide how a conflicting Set is handled.
+type Resolution int
+
+const (
+	// Keep leaves the existing bindings in place and discards the new pair.
+	Keep Resolution = iota
+	// Replace removes the existing bindings that conflict with the new pair and stores the new pair.
+	Replace
+	// Abort discards the new pair like Keep. Batch operations such as SetMany additionally stop processing
+	// the remaining pairs.
+	Abort
+)
+
+// WithOnConflict installs a conflict handler that is called by Set and SetMany whenever the new key is already
+// bound to a different value or the new value is already bound to a different key. The handler is called once
+// for every conflicting binding with that binding and the new pair, and the new pair is only stored if all
+// calls return Replace. A conflict handler takes precedence over strict mode. The key and value types of the
+// handler must match those of the map passed to New, otherwise New panics.
+func WithOnConflict[K, V comparable](fn func(existingKey K, existingValue V, newKey K, newValue V) Resolution) Option {
+	return func(c *config) {
+		c.onConflict = fn
+	}
+}
+
+// resolve consults the conflict handler for the given pair. It returns the resolution and true if the pair
+// conflicts with existing bindings, or false if there is no conflict or no conflict handler.
+func (m *Map[K, V]) resolve(key K, value V) (Resolution, bool) {
+	if m.onConflict == nil {
+		return Replace, false
+	}
+	res, conflicted := Replace, false
+	if v, ok := m.kv[key]; ok && v != value {
+		conflicted = true
+		res = m.onConflict(key, v, key, value)
+	}
+	if k, ok := m.vk[value]; ok && k != key && res == Replace {
+		conflicted = true
+		res = m.onConflict(k, value, key, value)
+	}
+	return res, conflicted
+}
+
+// encodingKeys returns the keys of the map in the order in which encoders write them: sorted if the map was
+// created with WithSortedEncoding or WithKeyOrder, and in unspecified order otherwise.
+func (m *Map[K, V]) encodingKeys() []K {
+	keys := m.Keys()
+	if m.keyOrder != nil {
+		slices.SortFunc(keys, m.keyOrder)
+	}
+	return keys
+}
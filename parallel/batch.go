@@ -0,0 +1,83 @@
+package parallel
+
+import (
+	"fmt"
+
+	"github.com/rasteric/doublemap"
+)
+
+// SetMany stores the given pairs in the map, skipping pairs whose key or value is already bound to a different
+// value or key, including bindings made by earlier pairs of the same call. The skipped pairs are returned in
+// the order given. The map is write locked once for all pairs, so other goroutines observe either none or all
+// of the stored pairs.
+func (m *Map[K, V]) SetMany(pairs []doublemap.Pair[K, V]) (conflicts []doublemap.Pair[K, V]) {
+	h := m.lock()
+	defer m.unlock(h)
+	for _, p := range pairs {
+		if m.conflict(p.Key, p.Value) != nil {
+			conflicts = append(conflicts, p)
+			continue
+		}
+		m.set(p.Key, p.Value)
+	}
+	return conflicts
+}
+
+// RemoveMany removes the mappings for the given keys under a single write lock and returns the number of
+// mappings actually removed.
+func (m *Map[K, V]) RemoveMany(keys ...K) int {
+	h := m.lock()
+	defer m.unlock(h)
+	n := 0
+	for _, k := range keys {
+		if _, ok := m.remove(k); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// RemoveManyByValue removes the mappings for the given values under a single write lock and returns the number
+// of mappings actually removed.
+func (m *Map[K, V]) RemoveManyByValue(values ...V) int {
+	h := m.lock()
+	defer m.unlock(h)
+	n := 0
+	for _, v := range values {
+		if _, ok := m.removeByValue(v); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// LoadFromMap stores all key-value pairs of src in the map under a single write lock. If the map is empty, its
+// indexes are allocated with room for all pairs first unless they are already sized for that many, which makes
+// LoadFromMap much faster than calling Set in a loop for warming up a map. Values that would end up bound to
+// more than one key are reported as an error wrapping doublemap.ErrDuplicateValue if they occur more than once
+// in src, or doublemap.ErrValueExists if they are bound to a key of the map that is not in src. On error the map
+// is left unchanged. The map does not take ownership of src.
+func (m *Map[K, V]) LoadFromMap(src map[K]V) error {
+	seen := make(map[V]K, len(src))
+	for k, v := range src {
+		if k2, ok := seen[v]; ok {
+			return fmt.Errorf("%w: %v is stored for keys %v and %v", doublemap.ErrDuplicateValue, v, k2, k)
+		}
+		seen[v] = k
+	}
+	h := m.lock()
+	defer m.unlock(h)
+	for k, v := range src {
+		// A key of the map that is in src is rebound to a different value, so it does not conflict.
+		if k2, ok := m.vk[v]; ok && k2 != k {
+			if _, rebound := src[k2]; !rebound {
+				return fmt.Errorf("%w: %v is bound to %v", doublemap.ErrValueExists, v, k2)
+			}
+		}
+	}
+	m.presize(len(src))
+	for k, v := range src {
+		m.bind(k, v)
+	}
+	return nil
+}
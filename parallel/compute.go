@@ -0,0 +1,70 @@
+package parallel
+
+import "fmt"
+
+// call is a computation of a missing value by GetOrCompute that other goroutines may wait for.
+type call[V comparable] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// GetOrCompute returns the existing value for the given key if the key is present. Otherwise, it calls fn to
+// construct a value and stores it for the key unless fn returns an error, which is passed on to the caller. The
+// map is not locked while fn runs, so fn may take long and may use the map. If several goroutines request the
+// same missing key concurrently, fn is only called once and all of them receive its result. If the key is set
+// by another goroutine while fn runs, the value set is kept and returned instead of the computed one. Looking up
+// a present key only takes the read lock.
+func (m *Map[K, V]) GetOrCompute(key K, fn func() (V, error)) (V, error) {
+	h := m.rlock()
+	v, ok := m.get(key)
+	m.runlock(h)
+	if ok {
+		return v, nil
+	}
+	// The key may have been set or a computation started since the read lock was released.
+	h = m.lock()
+	if v, ok := m.get(key); ok {
+		m.unlock(h)
+		return v, nil
+	}
+	if c, ok := m.calls[key]; ok {
+		m.unlock(h)
+		<-c.done
+		return c.value, c.err
+	}
+	c := &call[V]{done: make(chan struct{})}
+	if m.calls == nil {
+		m.calls = make(map[K]*call[V])
+	}
+	m.calls[key] = c
+	m.unlock(h)
+
+	completed := false
+	defer func() {
+		if !completed {
+			// fn panicked; release the waiters before the panic propagates.
+			c.err = fmt.Errorf("parallel: GetOrCompute for key %v panicked", key)
+			h := m.lock()
+			delete(m.calls, key)
+			m.unlock(h)
+			close(c.done)
+		}
+	}()
+	value, err := fn()
+	completed = true
+
+	h = m.lock()
+	delete(m.calls, key)
+	if err == nil {
+		if v, ok := m.get(key); ok {
+			value = v
+		} else {
+			m.set(key, value)
+		}
+	}
+	m.unlock(h)
+	c.value, c.err = value, err
+	close(c.done)
+	return value, err
+}
@@ -0,0 +1,151 @@
+package parallel
+
+import (
+	"fmt"
+
+	"github.com/rasteric/doublemap"
+)
+
+// This file contains the core operations of the map. They do not lock the map, so the caller must hold the
+// read lock for get and byValue and the write lock for all other operations. Public methods acquire the mutex
+// exactly once and then only use these operations, so they can be combined freely without deadlocking.
+
+// init allocates the internal maps if necessary.
+func (m *Map[K, V]) init() {
+	if m.kv == nil {
+		m.kv = make(map[K]V)
+	}
+	if m.vk == nil {
+		m.vk = make(map[V]K)
+	}
+}
+
+// presize allocates new indexes with room for n entries if the map is empty and its indexes are not already
+// sized for that many. Go maps cannot grow in place, so a non-empty map is left to grow as entries are added.
+func (m *Map[K, V]) presize(n int) {
+	if len(m.kv) > 0 || (m.kv != nil && n <= m.capacity) {
+		return
+	}
+	m.capacity = max(m.capacity, n)
+	m.kv = make(map[K]V, m.capacity)
+	m.vk = make(map[V]K, m.capacity)
+}
+
+// get returns the value for the given key.
+func (m *Map[K, V]) get(key K) (V, bool) {
+	value, ok := m.kv[key]
+	return value, ok
+}
+
+// byValue returns the key for the given value.
+func (m *Map[K, V]) byValue(value V) (K, bool) {
+	key, ok := m.vk[value]
+	return key, ok
+}
+
+// set stores the mapping from key to value in both indexes.
+func (m *Map[K, V]) set(key K, value V) {
+	m.init()
+	m.kv[key] = value
+	m.vk[value] = key
+	m.dirty = true
+	m.countSet()
+	m.wake(key)
+	m.emit(EventSet, key, value)
+}
+
+// wake releases the goroutines waiting in WaitFor for the given key.
+func (m *Map[K, V]) wake(key K) {
+	if w, ok := m.waiters[key]; ok {
+		close(w.ch)
+		delete(m.waiters, key)
+	}
+}
+
+// remove removes the mapping for the given key and returns the removed value.
+func (m *Map[K, V]) remove(key K) (V, bool) {
+	value, ok := m.kv[key]
+	if ok {
+		delete(m.kv, key)
+		delete(m.vk, value)
+		m.removed(key, value)
+	}
+	return value, ok
+}
+
+// removeByValue removes the mapping for the given value and returns the removed key.
+func (m *Map[K, V]) removeByValue(value V) (K, bool) {
+	key, ok := m.vk[value]
+	if ok {
+		delete(m.kv, key)
+		delete(m.vk, value)
+		m.removed(key, value)
+	}
+	return key, ok
+}
+
+// removed reports the removal of a single mapping to instrumentation, the OnRemove callback and subscribers.
+func (m *Map[K, V]) removed(key K, value V) {
+	m.dirty = true
+	m.countRemoves(1)
+	if m.onRemove != nil {
+		m.onRemove(key, value)
+	}
+	m.emit(EventRemove, key, value)
+}
+
+// clear removes all mappings and returns the number of mappings removed.
+func (m *Map[K, V]) clear() int {
+	n := len(m.kv)
+	m.dirty = m.dirty || n > 0 || len(m.vk) > 0
+	m.countRemoves(n)
+	if m.onRemove != nil {
+		for k, v := range m.kv {
+			m.onRemove(k, v)
+		}
+	}
+	for k := range m.kv { // better than one loop since this is optimized by compiler
+		delete(m.kv, k)
+	}
+	for k := range m.vk {
+		delete(m.vk, k)
+	}
+	var key K
+	var value V
+	m.emit(EventClear, key, value)
+	return n
+}
+
+// unlinkValue removes the reverse entry for value if it still points to key.
+func (m *Map[K, V]) unlinkValue(key K, value V) {
+	if k, ok := m.vk[value]; ok && k == key {
+		delete(m.vk, value)
+		m.dirty = true
+	}
+}
+
+// bind stores the mapping from key to value and removes any mapping that would contradict it, i.e., the
+// reverse entry of the previous value of key and the forward entry of a different key previously bound to
+// value.
+func (m *Map[K, V]) bind(key K, value V) {
+	if old, ok := m.kv[key]; ok {
+		m.unlinkValue(key, old)
+	}
+	if k, ok := m.vk[value]; ok && k != key {
+		delete(m.kv, k)
+		m.removed(k, value)
+	}
+	m.set(key, value)
+}
+
+// conflict returns an error wrapping doublemap.ErrKeyExists or doublemap.ErrValueExists if storing the given
+// pair would conflict with an existing mapping, nil otherwise.
+func (m *Map[K, V]) conflict(key K, value V) error {
+	if v, ok := m.kv[key]; ok && v != value {
+		return fmt.Errorf("%w: %v is bound to %v", doublemap.ErrKeyExists, key, v)
+	}
+	if k, ok := m.vk[value]; ok && k != key {
+		return fmt.Errorf("%w: %v is bound to %v", doublemap.ErrValueExists, value, k)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package parallel
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+)
+
+// A LockReport describes a lock of a map that was held longer than the threshold set by WithLockDiagnostics.
+type LockReport struct {
+	// Write is true for the write lock and false for the read lock.
+	Write bool
+	// Held is how long the lock was held.
+	Held time.Duration
+	// Method is the fully qualified name of the method of the map that acquired the lock.
+	Method string
+	// Caller is the file and line from which Method was called.
+	Caller string
+}
+
+// String returns a description of the report suitable for logging.
+func (r LockReport) String() string {
+	kind := "read"
+	if r.Write {
+		kind = "write"
+	}
+	return fmt.Sprintf("parallel: %s lock held for %v by %s called at %s", kind, r.Held, r.Method, r.Caller)
+}
+
+// diagnostics holds the configuration of lock diagnostics, see WithLockDiagnostics.
+type diagnostics struct {
+	threshold time.Duration
+	report    func(LockReport)
+}
+
+// WithLockDiagnostics records how long the locks of the map are held and by which call site, and calls report
+// whenever a lock was held longer than threshold, e.g. by a slow Walk that starves writers. The report
+// function is called after the lock has been released, so it may use the map, log the report or panic to
+// obtain a stack trace during testing. If report is nil, reports are written with the log package. Recording
+// the call site costs a stack walk per lock acquisition, so the option is meant for debugging.
+func WithLockDiagnostics(threshold time.Duration, report func(LockReport)) Option {
+	return func(c *config) {
+		c.diagnostics = &diagnostics{threshold: threshold, report: report}
+	}
+}
+
+// hold describes an acquisition of the lock of the map. Its zero value is used when neither metrics nor
+// diagnostics are enabled.
+type hold struct {
+	start time.Time
+	pcs   [2]uintptr // the method that acquired the lock and its caller
+	n     int
+}
+
+// lockStart returns the time at which waiting for a lock starts if the lock wait time is measured.
+func (m *Map[K, V]) lockStart() time.Time {
+	if m.metrics == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// acquired records the acquisition of a lock for which waiting began at start. The stack frames skipped when
+// recording the call site are counted from the caller of acquired, so skip is 1 if the method acquiring the
+// lock calls acquired directly.
+func (m *Map[K, V]) acquired(start time.Time, skip int) hold {
+	var h hold
+	if m.metrics != nil {
+		m.metrics.locks.Add(1)
+		m.metrics.lockWait.Add(uint64(time.Since(start)))
+	}
+	if m.diagnostics != nil {
+		h.n = runtime.Callers(skip+1, h.pcs[:])
+		h.start = time.Now()
+	}
+	return h
+}
+
+// released checks the duration of a hold of the lock after the lock has been released.
+func (m *Map[K, V]) released(h hold, write bool) {
+	if m.diagnostics == nil {
+		return
+	}
+	held := time.Since(h.start)
+	if held <= m.diagnostics.threshold {
+		return
+	}
+	r := LockReport{Write: write, Held: held}
+	frames := runtime.CallersFrames(h.pcs[:h.n])
+	if f, more := frames.Next(); f.Function != "" {
+		r.Method = f.Function
+		if more {
+			f, _ = frames.Next()
+			r.Caller = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+	}
+	if m.diagnostics.report == nil {
+		log.Print(r)
+		return
+	}
+	m.diagnostics.report(r)
+}
+
+// lock acquires the write lock of the map.
+func (m *Map[K, V]) lock() hold {
+	start := m.lockStart()
+	m.mutex.Lock()
+	return m.acquired(start, 2)
+}
+
+// unlock releases the write lock of the map.
+func (m *Map[K, V]) unlock(h hold) {
+	if m.dirty {
+		// Invalidate the copy used by optimistic readers before other goroutines can lock the map again.
+		if m.optimistic != nil {
+			m.optimistic.seq.Add(1)
+		}
+		m.dirty = false
+	}
+	m.mutex.Unlock()
+	m.released(h, true)
+}
+
+// rlock acquires the read lock of the map.
+func (m *Map[K, V]) rlock() hold {
+	return m.rlockFrom(1)
+}
+
+// rlockFrom acquires the read lock of the map like rlock for a helper method, attributing the hold to the method
+// skip frames above the caller of rlockFrom, so skip is 1 for a helper called directly by a public method.
+func (m *Map[K, V]) rlockFrom(skip int) hold {
+	start := m.lockStart()
+	m.mutex.RLock()
+	return m.acquired(start, skip+2)
+}
+
+// runlock releases the read lock of the map.
+func (m *Map[K, V]) runlock(h hold) {
+	m.mutex.RUnlock()
+	m.released(h, false)
+}
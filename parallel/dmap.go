@@ -1,94 +1,255 @@
 // Package doublemap/parallel provides a generic parallel Map[K comparable, V comparable] with operations for getting and setting
 // values by key, and the corresponding reverse map operation of getting and setting keys by values. The Map is
 // thread-safe and uses an internal read/write mutex for synchronization. Otherwise the map works exactly the same as doublemap.
-//
 package parallel
 
-import "sync"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rasteric/doublemap"
+)
 
 type Map[K comparable, V comparable] struct {
-	kv    map[K]V
-	vk    map[V]K
-	mutex sync.RWMutex
+	kv          map[K]V
+	vk          map[V]K
+	capacity    int                            // number of entries the indexes were last sized for
+	calls       map[K]*call[V]                 // computations in progress, see GetOrCompute
+	waiters     map[K]*waiter                  // see WaitFor
+	subscribers map[*subscriber[K, V]]struct{} // see Subscribe
+	keyLocks    map[K]*keyLock                 // see LockKey, guarded by keyMutex
+	keyMutex    sync.Mutex
+	metrics     *metrics          // nil unless created with WithMetrics
+	onRemove    func(K, V)        // see WithOnRemove
+	optimistic  *optimistic[K, V] // nil unless created with WithOptimisticReads
+	dirty       bool              // set when the indexes change while the map is write locked
+	diagnostics *diagnostics      // nil unless created with WithLockDiagnostics
+	mutex       sync.RWMutex
 }
 
-// New creates a new parallel double map.
-func New[K, V comparable]() *Map[K, V] {
-	return &Map[K, V]{kv: make(map[K]V), vk: make(map[V]K)}
+// New creates a new parallel double map configured by the given options.
+func New[K, V comparable](opts ...Option) *Map[K, V] {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	m := &Map[K, V]{kv: make(map[K]V, c.capacity), vk: make(map[V]K, c.capacity), capacity: c.capacity}
+	if c.metrics {
+		m.metrics = &metrics{}
+	}
+	m.diagnostics = c.diagnostics
+	if c.optimistic {
+		m.optimistic = &optimistic[K, V]{}
+	}
+	if c.onRemove != nil {
+		fn, ok := c.onRemove.(func(K, V))
+		if !ok {
+			panic("parallel: remove callback does not match the key and value types of the map")
+		}
+		m.onRemove = fn
+	}
+	return m
 }
 
 // Get returns the value for the given key and true, the null value of the value type and false if no value
 // was stored for this key.
 func (m *Map[K, V]) Get(key K) (V, bool) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	value, ok := m.kv[key]
+	if m.optimistic != nil {
+		if f := m.optimistic.current(); f != nil {
+			value, ok := f.kv[key]
+			m.countGet(ok)
+			return value, ok
+		}
+	}
+	h := m.rlock()
+	defer m.runlock(h)
+	if m.optimistic != nil {
+		m.refresh()
+	}
+	value, ok := m.get(key)
+	m.countGet(ok)
 	return value, ok
 }
 
 // Set sets a value for the given key.
 func (m *Map[K, V]) Set(key K, value V) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	m.kv[key] = value
-	m.vk[value] = key
+	h := m.lock()
+	defer m.unlock(h)
+	m.set(key, value)
 }
 
 // Remove removes the key and value mapping based on the given key. True is returned if the mapping was removed,
 // false is returned when there was no mapping for the key in the first place.
 func (m *Map[K, V]) Remove(key K) bool {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	value, ok := m.Get(key)
-	if ok {
-		delete(m.kv, key)
-		delete(m.vk, value)
-		return true
-	}
-	return false
+	h := m.lock()
+	defer m.unlock(h)
+	_, ok := m.remove(key)
+	return ok
 }
 
 // ByValue returns the key for a given value and true, the key type's null value and false if no key was
 // stored for this value.
 func (m *Map[K, V]) ByValue(value V) (K, bool) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	key, ok := m.vk[value]
+	if m.optimistic != nil {
+		if f := m.optimistic.current(); f != nil {
+			key, ok := f.vk[value]
+			m.countGet(ok)
+			return key, ok
+		}
+	}
+	h := m.rlock()
+	defer m.runlock(h)
+	if m.optimistic != nil {
+		m.refresh()
+	}
+	key, ok := m.byValue(value)
+	m.countGet(ok)
 	return key, ok
 }
 
 // RemoveByValue removes a given key-value mapping by the given value. True is returned if the mapping has been
 // removed, false is returned if there was no such value in the double map in the first place.
 func (m *Map[K, V]) RemoveByValue(value V) bool {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	key, ok := m.ByValue(value)
-	if ok {
-		delete(m.kv, key)
-		delete(m.vk, value)
-		return true
+	h := m.lock()
+	defer m.unlock(h)
+	_, ok := m.removeByValue(value)
+	return ok
+}
+
+// GetOrSet returns the existing value for the given key and true if the key is present. Otherwise, it stores
+// the given value for the key and returns it together with false. The lookup and the store happen under a
+// single write lock, so of several goroutines racing to set the same key exactly one stores its value and all
+// others get that value back.
+func (m *Map[K, V]) GetOrSet(key K, value V) (V, bool) {
+	h := m.lock()
+	defer m.unlock(h)
+	if v, ok := m.get(key); ok {
+		return v, true
+	}
+	m.set(key, value)
+	return value, false
+}
+
+// CompareAndSwap replaces the value for the given key with new and returns true if the key is present and its
+// current value equals old. Otherwise the map is left unchanged and false is returned. Both indexes are updated:
+// old can no longer be found by ByValue, and if new was bound to a different key, that key's mapping is removed.
+func (m *Map[K, V]) CompareAndSwap(key K, old, new V) bool {
+	h := m.lock()
+	defer m.unlock(h)
+	if v, ok := m.get(key); !ok || v != old {
+		return false
+	}
+	m.bind(key, new)
+	return true
+}
+
+// CompareAndDelete removes the mapping for the given key and returns true if the key is present and its
+// current value equals value. Otherwise the map is left unchanged and false is returned.
+func (m *Map[K, V]) CompareAndDelete(key K, value V) bool {
+	h := m.lock()
+	defer m.unlock(h)
+	if v, ok := m.get(key); !ok || v != value {
+		return false
+	}
+	m.remove(key)
+	return true
+}
+
+// Swap sets the value for the given key and returns the previous value and true, or the null value of the
+// value type and false if there was no value for the key. The previous value is retrieved and replaced under a
+// single write lock. Unlike Set, Swap also removes the reverse entry of the previous value, so it can no longer
+// be found by ByValue.
+func (m *Map[K, V]) Swap(key K, value V) (old V, loaded bool) {
+	h := m.lock()
+	defer m.unlock(h)
+	old, loaded = m.get(key)
+	if loaded {
+		m.unlinkValue(key, old)
 	}
-	return false
+	m.set(key, value)
+	return old, loaded
+}
+
+// SetIfAbsent stores the pair only if neither the key nor the value is present in the map, and returns true if
+// the pair was stored. The check and the store happen under a single write lock, so of several goroutines
+// registering the same key or value exactly one succeeds.
+func (m *Map[K, V]) SetIfAbsent(key K, value V) bool {
+	h := m.lock()
+	defer m.unlock(h)
+	if _, ok := m.get(key); ok {
+		return false
+	}
+	if _, ok := m.byValue(value); ok {
+		return false
+	}
+	m.set(key, value)
+	return true
 }
 
 // Copy creates a copy of the key-value mapping. This operation is fairly slow but faster than using Get and Set
-// manually. The copy is not deep, i.e., any key and values are just copied using ordinary assignment.
-func (m *Map[K, V]) Copy() *Map[K, V] {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	m2 := Map[K, V]{}
+// manually. The copy is not deep, i.e., any key and values are just copied using ordinary assignment. By
+// default all pairs are copied into maps allocated with room for them; use WithCopyCapacity and WithCopyFilter
+// to change this. The map is read locked while copying it.
+func (m *Map[K, V]) Copy(opts ...CopyOption) *Map[K, V] {
+	capacity, keep := m.copyConfig(opts)
+	h := m.rlock()
+	defer m.runlock(h)
+	if capacity < 0 {
+		capacity = len(m.kv)
+	}
+	m2 := New[K, V](WithCapacity(capacity))
+	for k, v := range m.kv {
+		if keep == nil || keep(k, v) {
+			m2.set(k, v)
+		}
+	}
+	return m2
+}
+
+// ToDoublemap works like Copy but returns the copy as a doublemap.Map, which is not safe for concurrent use but
+// has no locking overhead, e.g. for handing the contents of the map to single-threaded code.
+func (m *Map[K, V]) ToDoublemap(opts ...CopyOption) *doublemap.Map[K, V] {
+	capacity, keep := m.copyConfig(opts)
+	h := m.rlock()
+	defer m.runlock(h)
+	if capacity < 0 {
+		capacity = len(m.kv)
+	}
+	m2 := doublemap.NewWithCapacity[K, V](capacity)
+	for k, v := range m.kv {
+		if keep == nil || keep(k, v) {
+			m2.Set(k, v)
+		}
+	}
+	return m2
+}
+
+// CopyFunc creates a copy of the key-value mapping in which every key is duplicated with cloneK and every value
+// with cloneV. Either function may be nil, in which case keys or values are copied using ordinary assignment.
+// The map is read locked while copying it, so the clone functions must not call back into the map.
+func (m *Map[K, V]) CopyFunc(cloneK func(K) K, cloneV func(V) V) *Map[K, V] {
+	h := m.rlock()
+	defer m.runlock(h)
+	m2 := New[K, V](WithCapacity(len(m.kv)))
 	for k, v := range m.kv {
-		m2.kv[k] = v
-		m2.vk[v] = k
+		if cloneK != nil {
+			k = cloneK(k)
+		}
+		if cloneV != nil {
+			v = cloneV(v)
+		}
+		m2.set(k, v)
 	}
-	return &m2
+	return m2
 }
 
 // Walk traverses key-value pairs in the map and provides them to the given function in unspecified order
 // until the function returns false. The parallel map is read locked while walking it but not write locked.
 func (m *Map[K, V]) Walk(fn func(key K, value V) bool) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
+	h := m.rlock()
+	defer m.runlock(h)
 	for k, v := range m.kv {
 		if !fn(k, v) {
 			break
@@ -96,14 +257,87 @@ func (m *Map[K, V]) Walk(fn func(key K, value V) bool) {
 	}
 }
 
-// Clear clears the map, removing all key-valie pairs in it.
-func (m *Map[K, V]) Clear() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	for k := range m.kv { // better than one loop since this is optimized by compiler
-		delete(m.kv, k)
+// WalkSnapshot works like Walk but copies the key-value pairs under the read lock first and calls fn after
+// releasing it, so a slow fn does not block writers and may modify the map. Changes made while walking are not
+// observed by the walk.
+func (m *Map[K, V]) WalkSnapshot(fn func(key K, value V) bool) {
+	for _, p := range m.entries() {
+		if !fn(p.Key, p.Value) {
+			break
+		}
+	}
+}
+
+// entries returns the key-value pairs of the map, copied under the read lock. The hold of the lock is attributed
+// to the caller of entries.
+func (m *Map[K, V]) entries() []doublemap.Pair[K, V] {
+	h := m.rlockFrom(1)
+	defer m.runlock(h)
+	entries := make([]doublemap.Pair[K, V], 0, len(m.kv))
+	for k, v := range m.kv {
+		entries = append(entries, doublemap.Pair[K, V]{Key: k, Value: v})
+	}
+	return entries
+}
+
+// Clear clears the map, removing all key-value pairs in it, and returns the number of pairs removed. If the map
+// was created with WithOnRemove, the callback is called for every pair before it is removed.
+func (m *Map[K, V]) Clear() int {
+	h := m.lock()
+	defer m.unlock(h)
+	return m.clear()
+}
+
+// Len returns the number of key-value pairs in the map. The map is read locked while counting, which takes
+// constant time.
+func (m *Map[K, V]) Len() int {
+	h := m.rlock()
+	defer m.runlock(h)
+	return len(m.kv)
+}
+
+// Keys returns a snapshot of the keys in the map in unspecified order. The map is read locked while the keys
+// are copied, so the result is consistent and may be used afterwards without holding the lock.
+func (m *Map[K, V]) Keys() []K {
+	h := m.rlock()
+	defer m.runlock(h)
+	keys := make([]K, 0, len(m.kv))
+	for k := range m.kv {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns a snapshot of the values in the map in unspecified order. The map is read locked while the
+// values are copied, so the result is consistent and may be used afterwards without holding the lock.
+func (m *Map[K, V]) Values() []V {
+	h := m.rlock()
+	defer m.runlock(h)
+	values := make([]V, 0, len(m.kv))
+	for _, v := range m.kv {
+		values = append(values, v)
+	}
+	return values
+}
+
+// String returns the contents of the map in the form "{k1:v1, k2:v2, ...}". Entries are sorted by their
+// textual representation so that the output is deterministic. The map is read locked while rendering it.
+func (m *Map[K, V]) String() string {
+	return m.StringN(-1)
+}
+
+// StringN works like String but renders at most n entries, followed by "..." if the map has more entries.
+// A negative n renders all entries.
+func (m *Map[K, V]) StringN(n int) string {
+	h := m.rlock()
+	entries := make([]string, 0, len(m.kv))
+	for k, v := range m.kv {
+		entries = append(entries, fmt.Sprintf("%v:%v", k, v))
 	}
-	for k := range m.vk {
-		delete(m.vk, k)
+	m.runlock(h)
+	sort.Strings(entries)
+	if n >= 0 && n < len(entries) {
+		entries = append(entries[:n], "...")
 	}
+	return "{" + strings.Join(entries, ", ") + "}"
 }
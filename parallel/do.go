@@ -0,0 +1,73 @@
+package parallel
+
+// MapAccess provides unlocked access to a parallel map whose lock is held by the caller. See Do.
+type MapAccess[K comparable, V comparable] interface {
+	// Get returns the value for the given key and true, or the null value of the value type and false.
+	Get(key K) (V, bool)
+	// ByValue returns the key for the given value and true, or the null value of the key type and false.
+	ByValue(value V) (K, bool)
+	// Set sets a value for the given key.
+	Set(key K, value V)
+	// Remove removes the mapping for the given key and returns true if there was one.
+	Remove(key K) bool
+	// RemoveByValue removes the mapping for the given value and returns true if there was one.
+	RemoveByValue(value V) bool
+	// Len returns the number of key-value pairs in the map.
+	Len() int
+	// Walk traverses key-value pairs in unspecified order until fn returns false. The map must not be
+	// modified by fn.
+	Walk(fn func(key K, value V) bool)
+}
+
+// access implements MapAccess by calling the core operations of the map directly.
+type access[K comparable, V comparable] struct {
+	m *Map[K, V]
+}
+
+func (a access[K, V]) Get(key K) (V, bool) {
+	value, ok := a.m.get(key)
+	a.m.countGet(ok)
+	return value, ok
+}
+
+func (a access[K, V]) ByValue(value V) (K, bool) {
+	key, ok := a.m.byValue(value)
+	a.m.countGet(ok)
+	return key, ok
+}
+
+func (a access[K, V]) Set(key K, value V) {
+	a.m.set(key, value)
+}
+
+func (a access[K, V]) Remove(key K) bool {
+	_, ok := a.m.remove(key)
+	return ok
+}
+
+func (a access[K, V]) RemoveByValue(value V) bool {
+	_, ok := a.m.removeByValue(value)
+	return ok
+}
+
+func (a access[K, V]) Len() int {
+	return len(a.m.kv)
+}
+
+func (a access[K, V]) Walk(fn func(key K, value V) bool) {
+	for k, v := range a.m.kv {
+		if !fn(k, v) {
+			break
+		}
+	}
+}
+
+// Do calls fn with the map write locked once for the whole call, so fn can perform multi-step updates such as
+// read-modify-write sequences across several keys atomically. The tx passed to fn accesses the map without
+// locking; it must not be retained or used after fn returns, and fn must not call methods of the map itself,
+// which would deadlock.
+func (m *Map[K, V]) Do(fn func(tx MapAccess[K, V])) {
+	h := m.lock()
+	defer m.unlock(h)
+	fn(access[K, V]{m})
+}
@@ -0,0 +1,164 @@
+package parallel
+
+import "sync"
+
+// An EventKind describes the kind of change reported by an Event.
+type EventKind int
+
+const (
+	// EventSet reports that a value was set for a key.
+	EventSet EventKind = iota
+	// EventRemove reports that the mapping for a key was removed.
+	EventRemove
+	// EventClear reports that all mappings were removed. The key and value of the event are null values.
+	EventClear
+)
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventSet:
+		return "set"
+	case EventRemove:
+		return "remove"
+	case EventClear:
+		return "clear"
+	}
+	return "unknown"
+}
+
+// An Event describes a change of a parallel map delivered to subscribers, see Subscribe.
+type Event[K comparable, V comparable] struct {
+	Kind  EventKind
+	Key   K
+	Value V
+}
+
+// A DropPolicy decides what happens to an event when the buffer of a subscription is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the new event.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest buffered event to make room for the new one. Without a buffer there is no
+	// buffered event to discard, so the new event is discarded like with DropNewest if the subscriber is not
+	// ready to receive it.
+	DropOldest
+	// Block waits until the subscriber has received an event or cancelled the subscription. Since events are
+	// delivered while the map is write locked, a slow subscriber blocks all other users of the map.
+	Block
+)
+
+// DefaultEventBuffer is the number of events buffered for a subscription created without WithEventBuffer.
+const DefaultEventBuffer = 64
+
+// A SubscribeOption configures a subscription created by Subscribe.
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	buffer int
+	policy DropPolicy
+}
+
+// WithEventBuffer sets the number of events buffered for the subscriber. A buffer of zero delivers events only
+// to a subscriber that is ready to receive them, or waits for it with Block. WithEventBuffer panics if n is
+// negative.
+func WithEventBuffer(n int) SubscribeOption {
+	if n < 0 {
+		panic("parallel: negative event buffer")
+	}
+	return func(c *subscribeConfig) {
+		c.buffer = n
+	}
+}
+
+// WithDropPolicy sets what happens to events when the buffer is full. The default is DropNewest.
+func WithDropPolicy(policy DropPolicy) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.policy = policy
+	}
+}
+
+// subscriber is a subscription to the events of a map.
+type subscriber[K comparable, V comparable] struct {
+	ch     chan Event[K, V]
+	policy DropPolicy
+	done   chan struct{} // closed when the subscription is cancelled
+}
+
+// Subscribe returns a channel on which the changes of the map are delivered as events in the order in which
+// they are made, and a function that cancels the subscription and closes the channel. Setting a value emits
+// EventSet, removing a mapping by key or value EventRemove, and Clear EventClear. Replacing the contents of the
+// map by decoding emits EventClear followed by EventSet for every entry. Events that do not fit into the buffer
+// are handled according to the drop policy. The cancel function may be called more than once.
+func (m *Map[K, V]) Subscribe(opts ...SubscribeOption) (<-chan Event[K, V], func()) {
+	c := subscribeConfig{buffer: DefaultEventBuffer}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	s := &subscriber[K, V]{ch: make(chan Event[K, V], c.buffer), policy: c.policy, done: make(chan struct{})}
+	h := m.lock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[*subscriber[K, V]]struct{})
+	}
+	m.subscribers[s] = struct{}{}
+	m.unlock(h)
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			close(s.done) // releases a blocked delivery before taking the lock
+			h := m.lock()
+			delete(m.subscribers, s)
+			m.unlock(h)
+			close(s.ch)
+		})
+	}
+	return s.ch, cancel
+}
+
+// emit delivers an event to all subscribers. The caller must hold the write lock.
+func (m *Map[K, V]) emit(kind EventKind, key K, value V) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	e := Event[K, V]{Kind: kind, Key: key, Value: value}
+	for s := range m.subscribers {
+		s.deliver(e)
+	}
+}
+
+// deliver sends an event to the subscriber according to its drop policy.
+func (s *subscriber[K, V]) deliver(e Event[K, V]) {
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- e:
+		case <-s.done:
+		}
+	case DropOldest:
+		if cap(s.ch) == 0 {
+			// Nothing is buffered that could make room, so waiting for the buffer to drain would never end.
+			select {
+			case s.ch <- e:
+			default:
+			}
+			return
+		}
+		for {
+			select {
+			case s.ch <- e:
+				return
+			default:
+			}
+			select {
+			case <-s.ch:
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package parallel
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/rasteric/doublemap"
+)
+
+// GobEncode implements gob.GobEncoder. Only the key-value pairs are encoded, the reverse index is rebuilt by
+// GobDecode. The encoding is compatible with that of doublemap.Map. The map is read locked while its entries
+// are copied.
+func (m *Map[K, V]) GobEncode() ([]byte, error) {
+	h := m.rlock()
+	entries := make([]doublemap.Pair[K, V], 0, len(m.kv))
+	for k, v := range m.kv {
+		entries = append(entries, doublemap.Pair[K, V]{Key: k, Value: v})
+	}
+	m.runlock(h)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder. It replaces the contents of the map with the decoded pairs and rebuilds
+// the reverse index. If the same key or value occurs more than once, an error wrapping
+// doublemap.ErrDuplicateKey or doublemap.ErrDuplicateValue is returned and the map is left unchanged.
+func (m *Map[K, V]) GobDecode(data []byte) error {
+	var entries []doublemap.Pair[K, V]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entries); err != nil {
+		return err
+	}
+	kv := make(map[K]V, len(entries))
+	for _, p := range entries {
+		if _, ok := kv[p.Key]; ok {
+			return fmt.Errorf("%w: %v", doublemap.ErrDuplicateKey, p.Key)
+		}
+		kv[p.Key] = p.Value
+	}
+	return m.assign(kv)
+}
@@ -0,0 +1,16 @@
+package parallel
+
+import "iter"
+
+// All returns an iterator over the key-value pairs of the map in unspecified order. Each iteration works on a
+// snapshot of the map copied under the read lock when the iteration starts, and the lock is not held while the
+// loop body runs, so the body may call back into the map. Changes made while iterating are not observed.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, p := range m.entries() {
+			if !yield(p.Key, p.Value) {
+				return
+			}
+		}
+	}
+}
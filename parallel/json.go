@@ -0,0 +1,71 @@
+package parallel
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rasteric/doublemap"
+)
+
+// MarshalJSON implements json.Marshaler. The map is encoded as an ordinary JSON object mapping keys to values,
+// so the key type must be a string, an integer or implement encoding.TextMarshaler. The map is read locked
+// while encoding it.
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	h := m.rlock()
+	defer m.runlock(h)
+	if m.kv == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m.kv)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the contents of the map with the decoded JSON object
+// and rebuilds the reverse index. If the object contains the same value for more than one key, an error
+// wrapping doublemap.ErrDuplicateValue is returned and the map is left unchanged.
+func (m *Map[K, V]) UnmarshalJSON(data []byte) error {
+	var kv map[K]V
+	if err := json.Unmarshal(data, &kv); err != nil {
+		return err
+	}
+	return m.assign(kv)
+}
+
+// assign replaces the contents of the map with kv, which the map takes ownership of, after building the reverse
+// index for it. If kv contains the same value for more than one key, an error wrapping
+// doublemap.ErrDuplicateValue is returned and the map is left unchanged. The map is write locked while its
+// contents are replaced.
+func (m *Map[K, V]) assign(kv map[K]V) error {
+	if kv == nil {
+		kv = make(map[K]V)
+	}
+	vk := make(map[V]K, len(kv))
+	for k, v := range kv {
+		if k2, ok := vk[v]; ok {
+			return fmt.Errorf("%w: %v is stored for keys %v and %v", doublemap.ErrDuplicateValue, v, k2, k)
+		}
+		vk[v] = k
+	}
+	h := m.lock()
+	defer m.unlock(h)
+	if m.onRemove != nil {
+		for k, v := range m.kv {
+			m.onRemove(k, v)
+		}
+	}
+	m.kv, m.vk, m.capacity = kv, vk, len(kv)
+	m.dirty = true
+	if len(m.subscribers) > 0 {
+		var zeroK K
+		var zeroV V
+		m.emit(EventClear, zeroK, zeroV)
+		for k, v := range kv {
+			m.emit(EventSet, k, v)
+		}
+	}
+	for k := range m.waiters {
+		if _, ok := kv[k]; ok {
+			m.wake(k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package parallel
+
+import "sync"
+
+// keyLock is the mutex of a single key together with the number of goroutines holding or waiting for it.
+type keyLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+// LockKey acquires an exclusive lock scoped to the given key, waiting until no other goroutine holds it, and
+// returns a function that releases it. Key locks are advisory: they only exclude other callers of LockKey and
+// WithKeyLocked for the same key, not the methods of the map, which remains fully accessible while a key is
+// locked. This allows long operations on one entry, e.g. computing and storing a new value, to be serialized
+// without blocking access to the rest of the map. The key need not be present in the map. Calling the returned
+// function more than once has no effect.
+func (m *Map[K, V]) LockKey(key K) func() {
+	m.keyMutex.Lock()
+	kl, ok := m.keyLocks[key]
+	if !ok {
+		if m.keyLocks == nil {
+			m.keyLocks = make(map[K]*keyLock)
+		}
+		kl = &keyLock{}
+		m.keyLocks[key] = kl
+	}
+	kl.refs++
+	m.keyMutex.Unlock()
+	kl.mutex.Lock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			kl.mutex.Unlock()
+			m.keyMutex.Lock()
+			kl.refs--
+			if kl.refs == 0 {
+				delete(m.keyLocks, key)
+			}
+			m.keyMutex.Unlock()
+		})
+	}
+}
+
+// WithKeyLocked calls fn while holding the lock for the given key, see LockKey.
+func (m *Map[K, V]) WithKeyLocked(key K, fn func()) {
+	unlock := m.LockKey(key)
+	defer unlock()
+	fn()
+}
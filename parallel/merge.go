@@ -0,0 +1,32 @@
+package parallel
+
+import "github.com/rasteric/doublemap"
+
+// MergeFrom stores all key-value pairs of other in the map, handling conflicting pairs according to policy like
+// doublemap.Map.Merge. The pairs of other are copied under its read lock first, and then stored under a single
+// write lock of the map, so readers of the map observe either none or all of the merged pairs. With
+// doublemap.ErrorOnConflict, the first conflict found is returned as an error wrapping doublemap.ErrKeyExists
+// or doublemap.ErrValueExists and the map is left unchanged. The other map is not modified.
+func (m *Map[K, V]) MergeFrom(other *Map[K, V], policy doublemap.MergePolicy) error {
+	if other == m {
+		return nil
+	}
+	// The maps are never locked at the same time, so concurrent merges in opposite directions cannot deadlock.
+	entries := other.entries()
+	h := m.lock()
+	defer m.unlock(h)
+	if policy == doublemap.ErrorOnConflict {
+		for _, p := range entries {
+			if err := m.conflict(p.Key, p.Value); err != nil {
+				return err
+			}
+		}
+	}
+	for _, p := range entries {
+		if policy == doublemap.KeepExisting && m.conflict(p.Key, p.Value) != nil {
+			continue
+		}
+		m.bind(p.Key, p.Value)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package parallel
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics holds the operation counters of a map created with WithMetrics.
+type Metrics struct {
+	// GetHits and GetMisses count lookups by key or value that found or did not find a mapping.
+	GetHits, GetMisses uint64
+	// Sets counts values stored and Removes mappings removed, by any operation.
+	Sets, Removes uint64
+	// Locks counts acquisitions of the map lock and LockWait is the total time spent waiting for them.
+	Locks    uint64
+	LockWait time.Duration
+}
+
+// metrics holds the counters of an instrumented map.
+type metrics struct {
+	getHits, getMisses, sets, removes, locks, lockWait atomic.Uint64
+}
+
+// WithMetrics enables the operation counters reported by Metrics. Counting costs a few atomic operations per
+// call and measuring the lock wait time two clock readings per lock acquisition.
+func WithMetrics() Option {
+	return func(c *config) {
+		c.metrics = true
+	}
+}
+
+// Metrics returns the current values of the operation counters. All counters are zero unless the map was
+// created with WithMetrics.
+func (m *Map[K, V]) Metrics() Metrics {
+	if m.metrics == nil {
+		return Metrics{}
+	}
+	return Metrics{
+		GetHits:   m.metrics.getHits.Load(),
+		GetMisses: m.metrics.getMisses.Load(),
+		Sets:      m.metrics.sets.Load(),
+		Removes:   m.metrics.removes.Load(),
+		Locks:     m.metrics.locks.Load(),
+		LockWait:  time.Duration(m.metrics.lockWait.Load()),
+	}
+}
+
+// PublishExpvar publishes the operation counters and the length of the map as an expvar variable with the
+// given name. Like expvar.Publish, it panics if the name is already in use.
+func (m *Map[K, V]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return struct {
+			Metrics
+			Len int
+		}{m.Metrics(), m.Len()}
+	}))
+}
+
+// countGet counts a lookup if the map is instrumented.
+func (m *Map[K, V]) countGet(hit bool) {
+	if m.metrics == nil {
+		return
+	}
+	if hit {
+		m.metrics.getHits.Add(1)
+	} else {
+		m.metrics.getMisses.Add(1)
+	}
+}
+
+// countSet counts a stored value if the map is instrumented.
+func (m *Map[K, V]) countSet() {
+	if m.metrics != nil {
+		m.metrics.sets.Add(1)
+	}
+}
+
+// countRemoves counts removed mappings if the map is instrumented.
+func (m *Map[K, V]) countRemoves(n int) {
+	if m.metrics != nil {
+		m.metrics.removes.Add(uint64(n))
+	}
+}
@@ -0,0 +1,56 @@
+package parallel
+
+import "sync/atomic"
+
+// optimistic holds the state of the optimistic read path, see WithOptimisticReads.
+type optimistic[K comparable, V comparable] struct {
+	seq      atomic.Uint64 // incremented by writers that changed the map before releasing the write lock
+	frozen   atomic.Pointer[frozen[K, V]]
+	building atomic.Bool
+}
+
+// frozen is an immutable copy of the indexes taken when the sequence counter had the value seq.
+type frozen[K comparable, V comparable] struct {
+	seq uint64
+	kv  map[K]V
+	vk  map[V]K
+}
+
+// WithOptimisticReads enables an optimistic read path for Get and ByValue for maps that are read very often
+// and changed rarely. Readers look up an immutable copy of the map without locking, as long as a sequence
+// counter shows that no writer has changed the map since the copy was made. Operations that lock the map for
+// writing but leave it unchanged, such as GetOrSet for a present key, keep the copy valid. This avoids the cache line
+// contention of the read/write mutex on machines with many cores. After a change, readers fall back to the
+// read lock, and one of them copies the map again, which takes time proportional to its size; maps that are
+// changed frequently are therefore better off without this option.
+func WithOptimisticReads() Option {
+	return func(c *config) {
+		c.optimistic = true
+	}
+}
+
+// current returns the immutable copy of the map if it is up to date, nil otherwise.
+func (o *optimistic[K, V]) current() *frozen[K, V] {
+	if f := o.frozen.Load(); f != nil && f.seq == o.seq.Load() {
+		return f
+	}
+	return nil
+}
+
+// refresh makes a new immutable copy of the map if the current one is out of date and no other reader is
+// making one. The caller must hold the read lock, so no writer can change the map or the sequence counter.
+func (m *Map[K, V]) refresh() {
+	o := m.optimistic
+	if o.current() != nil || !o.building.CompareAndSwap(false, true) {
+		return
+	}
+	defer o.building.Store(false)
+	f := &frozen[K, V]{seq: o.seq.Load(), kv: make(map[K]V, len(m.kv)), vk: make(map[V]K, len(m.vk))}
+	for k, v := range m.kv {
+		f.kv[k] = v
+	}
+	for v, k := range m.vk {
+		f.vk[v] = k
+	}
+	o.frozen.Store(f)
+}
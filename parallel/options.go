@@ -0,0 +1,70 @@
+package parallel
+
+// An Option configures a Map created by New.
+type Option func(*config)
+
+type config struct {
+	capacity    int
+	metrics     bool
+	onRemove    any
+	optimistic  bool
+	diagnostics *diagnostics
+}
+
+// WithCapacity allocates the internal maps with room for the given number of entries.
+func WithCapacity(capacity int) Option {
+	return func(c *config) {
+		c.capacity = capacity
+	}
+}
+
+// WithOnRemove registers a callback that is called with every key-value pair removed from the map, whether by
+// Remove, RemoveByValue, Clear or any other operation, e.g. to release resources referenced by values. The
+// callback is called while the map is write locked, so it must not call back into the map. The key and value
+// types of fn must match those of the map passed to New, otherwise New panics.
+func WithOnRemove[K, V comparable](fn func(key K, value V)) Option {
+	return func(c *config) {
+		c.onRemove = fn
+	}
+}
+
+// A CopyOption configures Copy and ToDoublemap.
+type CopyOption func(*copyConfig)
+
+type copyConfig struct {
+	capacity int
+	filter   any
+}
+
+// WithCopyCapacity allocates the internal maps of the copy with room for the given number of entries instead of
+// the number of entries of the original, e.g. when the copy is going to grow.
+func WithCopyCapacity(capacity int) CopyOption {
+	return func(c *copyConfig) {
+		c.capacity = capacity
+	}
+}
+
+// WithCopyFilter copies only the key-value pairs for which keep returns true. The map is read locked while
+// keep is called, so keep must not call back into the map. The key and value types of keep must match those
+// of the map that is copied, otherwise the copy panics.
+func WithCopyFilter[K, V comparable](keep func(key K, value V) bool) CopyOption {
+	return func(c *copyConfig) {
+		c.filter = keep
+	}
+}
+
+// copyConfig returns the configuration for copying the map with the given options.
+func (m *Map[K, V]) copyConfig(opts []CopyOption) (capacity int, keep func(K, V) bool) {
+	c := copyConfig{capacity: -1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.filter != nil {
+		fn, ok := c.filter.(func(K, V) bool)
+		if !ok {
+			panic("parallel: copy filter does not match the key and value types of the map")
+		}
+		keep = fn
+	}
+	return c.capacity, keep
+}
@@ -0,0 +1,70 @@
+package parallel
+
+// A Snapshot is a read-only copy of a parallel map frozen at the moment it was taken. Since it is never
+// modified, it needs no locking, may be used by any number of goroutines and never blocks writers to the map.
+type Snapshot[K comparable, V comparable] struct {
+	kv map[K]V
+	vk map[V]K
+}
+
+// Snapshot returns a snapshot of the current contents of the map. The map is read locked while it is copied,
+// so the snapshot is consistent.
+func (m *Map[K, V]) Snapshot() *Snapshot[K, V] {
+	h := m.rlock()
+	defer m.runlock(h)
+	s := &Snapshot[K, V]{kv: make(map[K]V, len(m.kv)), vk: make(map[V]K, len(m.vk))}
+	for k, v := range m.kv {
+		s.kv[k] = v
+	}
+	for v, k := range m.vk {
+		s.vk[v] = k
+	}
+	return s
+}
+
+// Get returns the value for the given key and true, the null value of the value type and false if no value
+// was stored for this key.
+func (s *Snapshot[K, V]) Get(key K) (V, bool) {
+	value, ok := s.kv[key]
+	return value, ok
+}
+
+// ByValue returns the key for a given value and true, the key type's null value and false if no key was
+// stored for this value.
+func (s *Snapshot[K, V]) ByValue(value V) (K, bool) {
+	key, ok := s.vk[value]
+	return key, ok
+}
+
+// Len returns the number of key-value pairs in the snapshot.
+func (s *Snapshot[K, V]) Len() int {
+	return len(s.kv)
+}
+
+// Keys returns a newly allocated slice containing all keys of the snapshot in unspecified order.
+func (s *Snapshot[K, V]) Keys() []K {
+	keys := make([]K, 0, len(s.kv))
+	for k := range s.kv {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns a newly allocated slice containing all values of the snapshot in unspecified order.
+func (s *Snapshot[K, V]) Values() []V {
+	values := make([]V, 0, len(s.kv))
+	for _, v := range s.kv {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Walk traverses key-value pairs in the snapshot and provides them to the given function in unspecified order
+// until the function returns false.
+func (s *Snapshot[K, V]) Walk(fn func(key K, value V) bool) {
+	for k, v := range s.kv {
+		if !fn(k, v) {
+			break
+		}
+	}
+}
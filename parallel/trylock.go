@@ -0,0 +1,60 @@
+package parallel
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeout is returned by TryGet and TrySet if the lock of the map could not be acquired in time.
+var ErrTimeout = errors.New("parallel: timed out waiting for the map lock")
+
+// maxBackoff is the longest pause between two attempts to acquire the lock.
+const maxBackoff = time.Millisecond
+
+// tryFor calls try repeatedly with growing pauses until it returns true or the timeout has elapsed. It reports
+// whether try succeeded. A timeout of zero or less makes a single attempt.
+func tryFor(timeout time.Duration, try func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	backoff := time.Microsecond
+	for {
+		if try() {
+			return true
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		time.Sleep(min(backoff, remaining))
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// TryGet works like Get but gives up and returns ErrTimeout if the read lock cannot be acquired within the
+// given timeout, so latency-sensitive callers can degrade gracefully under contention. A timeout of zero or
+// less fails immediately if the map is write locked.
+func (m *Map[K, V]) TryGet(key K, timeout time.Duration) (V, bool, error) {
+	start := m.lockStart()
+	if !tryFor(timeout, m.mutex.TryRLock) {
+		var zero V
+		return zero, false, ErrTimeout
+	}
+	h := m.acquired(start, 1)
+	defer m.runlock(h)
+	value, ok := m.get(key)
+	m.countGet(ok)
+	return value, ok, nil
+}
+
+// TrySet works like Set but gives up and returns ErrTimeout, leaving the map unchanged, if the write lock
+// cannot be acquired within the given timeout. A timeout of zero or less fails immediately if the map is
+// locked.
+func (m *Map[K, V]) TrySet(key K, value V, timeout time.Duration) error {
+	start := m.lockStart()
+	if !tryFor(timeout, m.mutex.TryLock) {
+		return ErrTimeout
+	}
+	h := m.acquired(start, 1)
+	defer m.unlock(h)
+	m.set(key, value)
+	return nil
+}
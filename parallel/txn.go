@@ -0,0 +1,100 @@
+package parallel
+
+import "errors"
+
+// ErrTxDone is returned by operations on a transaction that has already been committed or rolled back.
+var ErrTxDone = errors.New("parallel: transaction has already been committed or rolled back")
+
+// opKind is the kind of a staged operation of a transaction.
+type opKind int
+
+const (
+	opSet opKind = iota
+	opRemove
+	opRemoveByValue
+)
+
+// op is an operation staged in a transaction.
+type op[K comparable, V comparable] struct {
+	kind  opKind
+	key   K
+	value V
+}
+
+// A Txn stages changes to a parallel map and applies them together on Commit, or discards them on Rollback.
+// Staged changes are not visible in the map, nor through the Txn, until they are committed. A Txn does not lock
+// the map before Commit and is not safe for concurrent use by multiple goroutines.
+type Txn[K comparable, V comparable] struct {
+	m    *Map[K, V]
+	ops  []op[K, V]
+	done bool
+}
+
+// Begin starts a new transaction on the map.
+func (m *Map[K, V]) Begin() *Txn[K, V] {
+	return &Txn[K, V]{m: m}
+}
+
+// Set stages setting a value for the given key.
+func (t *Txn[K, V]) Set(key K, value V) error {
+	return t.stage(op[K, V]{kind: opSet, key: key, value: value})
+}
+
+// Remove stages removing the mapping for the given key.
+func (t *Txn[K, V]) Remove(key K) error {
+	return t.stage(op[K, V]{kind: opRemove, key: key})
+}
+
+// RemoveByValue stages removing the mapping for the given value.
+func (t *Txn[K, V]) RemoveByValue(value V) error {
+	return t.stage(op[K, V]{kind: opRemoveByValue, value: value})
+}
+
+// stage appends an operation to the transaction.
+func (t *Txn[K, V]) stage(o op[K, V]) error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.ops = append(t.ops, o)
+	return nil
+}
+
+// Len returns the number of staged operations.
+func (t *Txn[K, V]) Len() int {
+	return len(t.ops)
+}
+
+// Commit applies all staged operations in the order they were staged under a single write lock, so other
+// goroutines observe either none or all of them. ErrTxDone is returned if the transaction has already been
+// committed or rolled back.
+func (t *Txn[K, V]) Commit() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	h := t.m.lock()
+	defer t.m.unlock(h)
+	for _, o := range t.ops {
+		switch o.kind {
+		case opSet:
+			t.m.set(o.key, o.value)
+		case opRemove:
+			t.m.remove(o.key)
+		case opRemoveByValue:
+			t.m.removeByValue(o.value)
+		}
+	}
+	t.ops = nil
+	return nil
+}
+
+// Rollback discards all staged operations. ErrTxDone is returned if the transaction has already been committed
+// or rolled back.
+func (t *Txn[K, V]) Rollback() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	t.ops = nil
+	return nil
+}
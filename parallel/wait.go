@@ -0,0 +1,51 @@
+package parallel
+
+import "context"
+
+// waiter is a channel that is closed when a key is set, shared by all goroutines waiting for the key in WaitFor.
+type waiter struct {
+	ch chan struct{}
+	n  int // number of goroutines waiting
+}
+
+// WaitFor returns the value for the given key, waiting until some goroutine sets the key if it is not present.
+// If ctx is cancelled or its deadline passes first, the context's error is returned. Waiting does not hold the
+// lock of the map, so the map can serve as a rendezvous point for producers and consumers.
+func (m *Map[K, V]) WaitFor(ctx context.Context, key K) (V, error) {
+	h := m.rlock()
+	value, ok := m.get(key)
+	m.runlock(h)
+	if ok {
+		return value, nil
+	}
+	for {
+		h := m.lock()
+		if value, ok := m.get(key); ok {
+			m.unlock(h)
+			return value, nil
+		}
+		w, ok := m.waiters[key]
+		if !ok {
+			if m.waiters == nil {
+				m.waiters = make(map[K]*waiter)
+			}
+			w = &waiter{ch: make(chan struct{})}
+			m.waiters[key] = w
+		}
+		w.n++
+		m.unlock(h)
+		select {
+		case <-w.ch:
+			// The key has been set, but it may have been removed again before the lock is reacquired.
+		case <-ctx.Done():
+			h := m.lock()
+			// The last goroutine to give up removes the waiter, unless the key has been set meanwhile.
+			if w.n--; w.n == 0 && m.waiters[key] == w {
+				delete(m.waiters, key)
+			}
+			m.unlock(h)
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+}
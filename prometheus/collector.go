@@ -0,0 +1,93 @@
+// Package doublemap/prometheus provides a Prometheus collector that exports the size and the operation counters
+// of parallel maps, see doublemap/parallel.WithMetrics.
+package prometheus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rasteric/doublemap/parallel"
+)
+
+// A Source is a map whose metrics can be collected. Every *parallel.Map is a Source, whatever its key and value
+// types.
+type Source interface {
+	Len() int
+	Metrics() parallel.Metrics
+}
+
+// A Collector is a prometheus.Collector for one or more named maps. Each metric carries the name of the map in
+// the label "map". Operation and lock metrics are only meaningful for maps created with parallel.WithMetrics.
+type Collector struct {
+	mutex   sync.RWMutex
+	sources map[string]Source
+
+	entries, getHits, getMisses, sets, removes, locks, lockWait *prometheus.Desc
+}
+
+// NewCollector returns a collector whose metric names start with the given namespace, e.g. "myapp" results in
+// "myapp_doublemap_entries". An empty namespace omits the prefix.
+func NewCollector(namespace string) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "doublemap", name), help, []string{"map"}, nil)
+	}
+	return &Collector{
+		sources:   make(map[string]Source),
+		entries:   desc("entries", "Number of key-value pairs in the map."),
+		getHits:   desc("get_hits_total", "Lookups by key or value that found a mapping."),
+		getMisses: desc("get_misses_total", "Lookups by key or value that found no mapping."),
+		sets:      desc("sets_total", "Values stored in the map."),
+		removes:   desc("removes_total", "Mappings removed from the map."),
+		locks:     desc("lock_acquisitions_total", "Acquisitions of the map lock."),
+		lockWait:  desc("lock_wait_seconds_total", "Total time spent waiting for the map lock."),
+	}
+}
+
+// Add adds a map to the collector under the given name, replacing any map added before under the same name.
+func (c *Collector) Add(name string, m Source) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.sources[name] = m
+}
+
+// Remove removes the map with the given name from the collector.
+func (c *Collector) Remove(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.sources, name)
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.entries, c.getHits, c.getMisses, c.sets, c.removes, c.locks,
+		c.lockWait} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	names := make([]string, 0, len(c.sources))
+	for name := range c.sources {
+		names = append(names, name)
+	}
+	sources := make([]Source, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		sources[i] = c.sources[name]
+	}
+	c.mutex.RUnlock()
+	for i, s := range sources {
+		name := names[i]
+		mt := s.Metrics()
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(s.Len()), name)
+		ch <- prometheus.MustNewConstMetric(c.getHits, prometheus.CounterValue, float64(mt.GetHits), name)
+		ch <- prometheus.MustNewConstMetric(c.getMisses, prometheus.CounterValue, float64(mt.GetMisses), name)
+		ch <- prometheus.MustNewConstMetric(c.sets, prometheus.CounterValue, float64(mt.Sets), name)
+		ch <- prometheus.MustNewConstMetric(c.removes, prometheus.CounterValue, float64(mt.Removes), name)
+		ch <- prometheus.MustNewConstMetric(c.locks, prometheus.CounterValue, float64(mt.Locks), name)
+		ch <- prometheus.MustNewConstMetric(c.lockWait, prometheus.CounterValue, mt.LockWait.Seconds(), name)
+	}
+}
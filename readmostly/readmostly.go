@@ -0,0 +1,161 @@
+// Package doublemap/readmostly provides a generic concurrent Map[K comparable, V comparable] with the
+// operations of doublemap/parallel, tuned for read-mostly workloads with many lookups and rare changes. Both
+// indexes are stored in a sync.Map, so Get and ByValue never lock and do not contend with each other. Writers
+// are serialized by a mutex to keep the two indexes in step.
+package readmostly
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A Map is a double map optimized for reading. It is safe for concurrent use, and its zero value is an empty
+// map ready to use. Since the indexes are updated one after the other, a reader running concurrently with a
+// writer may find a new pair by key slightly before it can find it by value and vice versa.
+type Map[K comparable, V comparable] struct {
+	kv    sync.Map // K -> V
+	vk    sync.Map // V -> K
+	n     atomic.Int64
+	mutex sync.Mutex // serializes writers
+}
+
+// New creates a new read-optimized double map.
+func New[K, V comparable]() *Map[K, V] {
+	return &Map[K, V]{}
+}
+
+// Get returns the value for the given key and true, the null value of the value type and false if no value
+// was stored for this key. Get does not lock the map.
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	value, ok := m.kv.Load(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return value.(V), true
+}
+
+// ByValue returns the key for a given value and true, the key type's null value and false if no key was
+// stored for this value. ByValue does not lock the map.
+func (m *Map[K, V]) ByValue(value V) (K, bool) {
+	key, ok := m.vk.Load(value)
+	if !ok {
+		var zero K
+		return zero, false
+	}
+	return key.(K), true
+}
+
+// Set sets a value for the given key.
+func (m *Map[K, V]) Set(key K, value V) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, loaded := m.kv.Swap(key, value); !loaded {
+		m.n.Add(1)
+	}
+	m.vk.Store(value, key)
+}
+
+// Remove removes the key and value mapping based on the given key. True is returned if the mapping was removed,
+// false is returned when there was no mapping for the key in the first place.
+func (m *Map[K, V]) Remove(key K) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.kv.LoadAndDelete(key)
+	if ok {
+		m.n.Add(-1)
+		m.vk.Delete(value)
+	}
+	return ok
+}
+
+// RemoveByValue removes a given key-value mapping by the given value. True is returned if the mapping has been
+// removed, false is returned if there was no such value in the double map in the first place.
+func (m *Map[K, V]) RemoveByValue(value V) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key, ok := m.vk.LoadAndDelete(value)
+	if ok {
+		if _, loaded := m.kv.LoadAndDelete(key); loaded {
+			m.n.Add(-1)
+		}
+	}
+	return ok
+}
+
+// Len returns the number of key-value pairs in the map. Len does not lock the map.
+func (m *Map[K, V]) Len() int {
+	return int(m.n.Load())
+}
+
+// Walk traverses key-value pairs in the map and provides them to the given function in unspecified order
+// until the function returns false. Walk does not lock the map and has the semantics of sync.Map.Range: no
+// pair is visited more than once, but changes made while walking may or may not be reflected. Unlike with the
+// other map types, fn may modify the map.
+func (m *Map[K, V]) Walk(fn func(key K, value V) bool) {
+	m.kv.Range(func(k, v any) bool {
+		return fn(k.(K), v.(V))
+	})
+}
+
+// Keys returns the keys in the map in unspecified order.
+func (m *Map[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Len())
+	m.Walk(func(key K, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns the values in the map in unspecified order.
+func (m *Map[K, V]) Values() []V {
+	values := make([]V, 0, m.Len())
+	m.Walk(func(_ K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Copy creates a copy of the key-value mapping. The copy is not deep, i.e., any key and values are just copied
+// using ordinary assignment. Writers are blocked while copying, so the copy is consistent.
+func (m *Map[K, V]) Copy() *Map[K, V] {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m2 := New[K, V]()
+	m.kv.Range(func(k, v any) bool {
+		m2.kv.Store(k, v)
+		return true
+	})
+	m.vk.Range(func(v, k any) bool {
+		m2.vk.Store(v, k)
+		return true
+	})
+	m2.n.Store(m.n.Load())
+	return m2
+}
+
+// Clear clears the map, removing all key-value pairs in it.
+func (m *Map[K, V]) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.kv.Clear()
+	m.vk.Clear()
+	m.n.Store(0)
+}
+
+// String returns the contents of the map in the form "{k1:v1, k2:v2, ...}". Entries are sorted by their
+// textual representation so that the output is deterministic.
+func (m *Map[K, V]) String() string {
+	var entries []string
+	m.Walk(func(k K, v V) bool {
+		entries = append(entries, fmt.Sprintf("%v:%v", k, v))
+		return true
+	})
+	sort.Strings(entries)
+	return "{" + strings.Join(entries, ", ") + "}"
+}
@@ -0,0 +1,103 @@
+package doublemap
+
+// Equal returns true if the map and other contain exactly the same key-value pairs, false otherwise.
+func (m *Map[K, V]) Equal(other *Map[K, V]) bool {
+	if len(m.kv) != len(other.kv) {
+		return false
+	}
+	for k, v := range m.kv {
+		if v2, ok := other.kv[k]; !ok || v2 != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Difference returns a new map containing the key-value pairs of m that are not contained in other. A pair is
+// only considered contained in other if other maps the same key to the same value.
+func (m *Map[K, V]) Difference(other *Map[K, V]) *Map[K, V] {
+	m2 := m.derive(0)
+	for k, v := range m.kv {
+		if v2, ok := other.kv[k]; !ok || v2 != v {
+			m2.kv[k] = v
+			m2.vk[v] = k
+		}
+	}
+	return m2
+}
+
+// KeyDifference returns a new map containing the key-value pairs of m whose keys are not present in other,
+// regardless of the values stored for them.
+func (m *Map[K, V]) KeyDifference(other *Map[K, V]) *Map[K, V] {
+	m2 := m.derive(0)
+	for k, v := range m.kv {
+		if _, ok := other.kv[k]; !ok {
+			m2.kv[k] = v
+			m2.vk[v] = k
+		}
+	}
+	return m2
+}
+
+// Intersect returns a new map containing the key-value pairs that are contained in both m and other, i.e.,
+// the keys that both maps map to the same value.
+func (m *Map[K, V]) Intersect(other *Map[K, V]) *Map[K, V] {
+	m2 := m.derive(0)
+	for k, v := range m.kv {
+		if v2, ok := other.kv[k]; ok && v2 == v {
+			m2.kv[k] = v
+			m2.vk[v] = k
+		}
+	}
+	return m2
+}
+
+// A Conflict describes a pair that could not be stored because its key or value was already bound to
+// something else.
+type Conflict[K comparable, V comparable] struct {
+	// Existing is the binding that was kept.
+	Existing Pair[K, V]
+	// Rejected is the pair that was not stored.
+	Rejected Pair[K, V]
+}
+
+// Union returns a new map containing the key-value pairs of both a and b. Pairs of b whose key or value is
+// bound to something else in a are not stored and reported as conflicts instead, one for every conflicting
+// binding, leaving the resolution to the caller. The new map has the configuration of a.
+func Union[K, V comparable](a, b *Map[K, V]) (*Map[K, V], []Conflict[K, V]) {
+	m := a.derive(len(a.kv) + len(b.kv))
+	for k, v := range a.kv {
+		m.kv[k] = v
+		m.vk[v] = k
+	}
+	var conflicts []Conflict[K, V]
+	for k, v := range b.kv {
+		ok := true
+		if v2, found := m.kv[k]; found && v2 != v {
+			conflicts = append(conflicts, Conflict[K, V]{Existing: Pair[K, V]{k, v2}, Rejected: Pair[K, V]{k, v}})
+			ok = false
+		}
+		if k2, found := m.vk[v]; found && k2 != k {
+			conflicts = append(conflicts, Conflict[K, V]{Existing: Pair[K, V]{k2, v}, Rejected: Pair[K, V]{k, v}})
+			ok = false
+		}
+		if ok {
+			m.kv[k] = v
+			m.vk[v] = k
+		}
+	}
+	return m, conflicts
+}
+
+// IsSubmapOf returns true if every key-value pair of m is also contained in other, false otherwise.
+func (m *Map[K, V]) IsSubmapOf(other *Map[K, V]) bool {
+	if len(m.kv) > len(other.kv) {
+		return false
+	}
+	for k, v := range m.kv {
+		if v2, ok := other.kv[k]; !ok || v2 != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,270 @@
+// Package doublemap/sharded provides a generic concurrent Map[K comparable, V comparable] with the operations of
+// doublemap/parallel, for workloads with heavy write contention. Both indexes are partitioned across a number
+// of shards with a read/write mutex each: key-value pairs are routed to a forward shard by the hash of the key
+// and value-key pairs to a reverse shard by the hash of the value, so operations on unrelated entries rarely
+// contend for the same lock.
+package sharded
+
+import (
+	"fmt"
+	"hash/maphash"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultShards is the number of shards of a map created without WithShards.
+const DefaultShards = 32
+
+// shard is one partition of an index.
+type shard[A comparable, B comparable] struct {
+	mutex sync.RWMutex
+	m     map[A]B
+}
+
+// A Map is a double map whose indexes are partitioned across shards. It is safe for concurrent use. Operations
+// that concern a single entry are atomic, but Len, Walk, Keys, Values and Copy visit the shards one after the
+// other and do not observe the map at a single point in time while other goroutines modify it.
+type Map[K comparable, V comparable] struct {
+	seed    maphash.Seed
+	mask    uint64
+	forward []shard[K, V]
+	reverse []shard[V, K]
+}
+
+// An Option configures a Map created by New.
+type Option func(*config)
+
+type config struct {
+	shards   int
+	capacity int
+}
+
+// WithShards sets the number of shards per index, which is rounded up to a power of two. More shards reduce
+// contention between writers at the cost of memory and of slower operations on the whole map.
+func WithShards(n int) Option {
+	return func(c *config) {
+		c.shards = n
+	}
+}
+
+// WithCapacity allocates the shards with room for the given total number of entries.
+func WithCapacity(capacity int) Option {
+	return func(c *config) {
+		c.capacity = capacity
+	}
+}
+
+// New creates a new sharded double map configured by the given options.
+func New[K, V comparable](opts ...Option) *Map[K, V] {
+	c := config{shards: DefaultShards}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	n := 1
+	for n < c.shards {
+		n <<= 1
+	}
+	m := &Map[K, V]{seed: maphash.MakeSeed(), mask: uint64(n - 1), forward: make([]shard[K, V], n),
+		reverse: make([]shard[V, K], n)}
+	for i := range n {
+		m.forward[i].m = make(map[K]V, c.capacity/n)
+		m.reverse[i].m = make(map[V]K, c.capacity/n)
+	}
+	return m
+}
+
+// forwardShard returns the shard of the forward index responsible for key.
+func (m *Map[K, V]) forwardShard(key K) *shard[K, V] {
+	return &m.forward[maphash.Comparable(m.seed, key)&m.mask]
+}
+
+// reverseShard returns the shard of the reverse index responsible for value.
+func (m *Map[K, V]) reverseShard(value V) *shard[V, K] {
+	return &m.reverse[maphash.Comparable(m.seed, value)&m.mask]
+}
+
+// Get returns the value for the given key and true, the null value of the value type and false if no value
+// was stored for this key.
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	f := m.forwardShard(key)
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	value, ok := f.m[key]
+	return value, ok
+}
+
+// ByValue returns the key for a given value and true, the key type's null value and false if no key was
+// stored for this value.
+func (m *Map[K, V]) ByValue(value V) (K, bool) {
+	r := m.reverseShard(value)
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	key, ok := r.m[value]
+	return key, ok
+}
+
+// Set sets a value for the given key.
+func (m *Map[K, V]) Set(key K, value V) {
+	// Forward shards are always locked before reverse shards to avoid deadlocks.
+	f, r := m.forwardShard(key), m.reverseShard(value)
+	f.mutex.Lock()
+	r.mutex.Lock()
+	f.m[key] = value
+	r.m[value] = key
+	r.mutex.Unlock()
+	f.mutex.Unlock()
+}
+
+// Remove removes the key and value mapping based on the given key. True is returned if the mapping was removed,
+// false is returned when there was no mapping for the key in the first place.
+func (m *Map[K, V]) Remove(key K) bool {
+	f := m.forwardShard(key)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	value, ok := f.m[key]
+	if !ok {
+		return false
+	}
+	r := m.reverseShard(value)
+	r.mutex.Lock()
+	delete(r.m, value)
+	r.mutex.Unlock()
+	delete(f.m, key)
+	return true
+}
+
+// RemoveByValue removes a given key-value mapping by the given value. True is returned if the mapping has been
+// removed, false is returned if there was no such value in the double map in the first place.
+func (m *Map[K, V]) RemoveByValue(value V) bool {
+	r := m.reverseShard(value)
+	for {
+		key, ok := m.ByValue(value)
+		if !ok {
+			return false
+		}
+		// The forward shard of key must be locked first, so the key is looked up again once both shards are
+		// locked and the removal is retried if it changed in between.
+		f := m.forwardShard(key)
+		f.mutex.Lock()
+		r.mutex.Lock()
+		current, ok := r.m[value]
+		if ok && current == key {
+			delete(r.m, value)
+			delete(f.m, key)
+		}
+		r.mutex.Unlock()
+		f.mutex.Unlock()
+		if !ok || current == key {
+			return ok
+		}
+	}
+}
+
+// Len returns the number of key-value pairs in the map.
+func (m *Map[K, V]) Len() int {
+	n := 0
+	for i := range m.forward {
+		f := &m.forward[i]
+		f.mutex.RLock()
+		n += len(f.m)
+		f.mutex.RUnlock()
+	}
+	return n
+}
+
+// Walk traverses key-value pairs in the map and provides them to the given function in unspecified order
+// until the function returns false. Each shard is read locked while it is walked, so fn must not modify the
+// map.
+func (m *Map[K, V]) Walk(fn func(key K, value V) bool) {
+	for i := range m.forward {
+		f := &m.forward[i]
+		f.mutex.RLock()
+		for k, v := range f.m {
+			if !fn(k, v) {
+				f.mutex.RUnlock()
+				return
+			}
+		}
+		f.mutex.RUnlock()
+	}
+}
+
+// Keys returns a snapshot of the keys in the map in unspecified order.
+func (m *Map[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Len())
+	m.Walk(func(key K, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns a snapshot of the values in the map in unspecified order.
+func (m *Map[K, V]) Values() []V {
+	values := make([]V, 0, m.Len())
+	m.Walk(func(_ K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Copy creates a copy of the map with the same number of shards. The copy is not deep, i.e., any key and values
+// are just copied using ordinary assignment.
+func (m *Map[K, V]) Copy() *Map[K, V] {
+	m2 := &Map[K, V]{seed: m.seed, mask: m.mask, forward: make([]shard[K, V], len(m.forward)),
+		reverse: make([]shard[V, K], len(m.reverse))}
+	for i := range m.forward {
+		f := &m.forward[i]
+		f.mutex.RLock()
+		m2.forward[i].m = make(map[K]V, len(f.m))
+		for k, v := range f.m {
+			m2.forward[i].m[k] = v
+		}
+		f.mutex.RUnlock()
+	}
+	for i := range m.reverse {
+		r := &m.reverse[i]
+		r.mutex.RLock()
+		m2.reverse[i].m = make(map[V]K, len(r.m))
+		for v, k := range r.m {
+			m2.reverse[i].m[v] = k
+		}
+		r.mutex.RUnlock()
+	}
+	return m2
+}
+
+// Clear clears the map, removing all key-value pairs in it. All shards are locked at once, so other goroutines
+// observe the map either before or after clearing it.
+func (m *Map[K, V]) Clear() {
+	for i := range m.forward {
+		m.forward[i].mutex.Lock()
+	}
+	for i := range m.reverse {
+		m.reverse[i].mutex.Lock()
+	}
+	for i := range m.forward {
+		clear(m.forward[i].m)
+		clear(m.reverse[i].m)
+	}
+	for i := range m.reverse {
+		m.reverse[i].mutex.Unlock()
+	}
+	for i := range m.forward {
+		m.forward[i].mutex.Unlock()
+	}
+}
+
+// String returns the contents of the map in the form "{k1:v1, k2:v2, ...}". Entries are sorted by their
+// textual representation so that the output is deterministic.
+func (m *Map[K, V]) String() string {
+	var entries []string
+	m.Walk(func(k K, v V) bool {
+		entries = append(entries, fmt.Sprintf("%v:%v", k, v))
+		return true
+	})
+	sort.Strings(entries)
+	return "{" + strings.Join(entries, ", ") + "}"
+}
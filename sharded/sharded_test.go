@@ -0,0 +1,71 @@
+package sharded_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/rasteric/doublemap/parallel"
+	"github.com/rasteric/doublemap/sharded"
+)
+
+// benchKeys is the number of distinct keys used by the benchmarks.
+const benchKeys = 1 << 16
+
+// concurrentMap is the API shared by the maps compared in the benchmarks.
+type concurrentMap interface {
+	Get(key int) (int, bool)
+	Set(key, value int)
+}
+
+// benchMap is a map to compare, named after its package.
+type benchMap struct {
+	name string
+	m    concurrentMap
+}
+
+// benchMaps returns the maps to compare, prefilled with benchKeys entries.
+func benchMaps() []benchMap {
+	ms := []benchMap{
+		{"parallel", parallel.New[int, int](parallel.WithCapacity(benchKeys))},
+		{"sharded", sharded.New[int, int](sharded.WithCapacity(benchKeys))},
+	}
+	for _, bm := range ms {
+		for i := range benchKeys {
+			bm.m.Set(i, i)
+		}
+	}
+	return ms
+}
+
+// benchmark runs random operations concurrently on every map to compare. The fraction of calls that are writes is given by
+// writes, the remaining calls are lookups.
+func benchmark(b *testing.B, writes float64) {
+	for _, bm := range benchMaps() {
+		m := bm.m
+		b.Run(bm.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+				for pb.Next() {
+					k := r.IntN(benchKeys)
+					if r.Float64() < writes {
+						m.Set(k, k)
+					} else {
+						m.Get(k)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	benchmark(b, 1)
+}
+
+func BenchmarkGet(b *testing.B) {
+	benchmark(b, 0)
+}
+
+func BenchmarkMixed(b *testing.B) {
+	benchmark(b, 0.1)
+}
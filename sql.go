@@ -0,0 +1,26 @@
+package doublemap
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value implements driver.Valuer, so a map can be written to a database column directly. The map is stored as
+// its JSON encoding, see MarshalJSON.
+func (m *Map[K, V]) Value() (driver.Value, error) {
+	return m.MarshalJSON()
+}
+
+// Scan implements sql.Scanner, so a map can be read from a database column holding its JSON encoding, as
+// written by Value. A NULL column results in an empty map.
+func (m *Map[K, V]) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		return m.assign(nil)
+	case []byte:
+		return m.UnmarshalJSON(src)
+	case string:
+		return m.UnmarshalJSON([]byte(src))
+	}
+	return fmt.Errorf("doublemap: cannot scan %T into a map", src)
+}
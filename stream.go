@@ -0,0 +1,165 @@
+package doublemap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// An Encoder writes maps to an output stream in the binary format of MarshalBinary, one entry at a time, so
+// the encoding of a huge map is never held in memory. Unlike MarshalBinary, the entries are written in
+// unspecified order, unless the map was created with WithSortedEncoding or WithKeyOrder, in which case only
+// the sorted keys are held in memory.
+type Encoder[K comparable, V comparable] struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder[K, V comparable](w io.Writer) *Encoder[K, V] {
+	return &Encoder[K, V]{w: bufio.NewWriter(w)}
+}
+
+// Encode writes the contents of m to the stream. The output can be read with a Decoder or UnmarshalBinary.
+func (e *Encoder[K, V]) Encode(m *Map[K, V]) error {
+	e.buf = appendHeader(e.buf[:0], m.schemaVersion)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(m.kv)))
+	if _, err := e.w.Write(e.buf); err != nil {
+		return err
+	}
+	if m.keyOrder != nil {
+		for _, k := range m.encodingKeys() {
+			if err := e.writeEntry(k, m.kv[k]); err != nil {
+				return err
+			}
+		}
+	} else {
+		for k, v := range m.kv {
+			if err := e.writeEntry(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return e.w.Flush()
+}
+
+// writeEntry writes a single key-value pair to the stream.
+func (e *Encoder[K, V]) writeEntry(k K, v V) error {
+	var err error
+	if e.buf, err = appendValue(e.buf[:0], reflect.ValueOf(&k).Elem()); err != nil {
+		return err
+	}
+	if e.buf, err = appendValue(e.buf, reflect.ValueOf(&v).Elem()); err != nil {
+		return err
+	}
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// A Decoder reads maps written by an Encoder or MarshalBinary from an input stream, one entry at a time.
+// Since migrations operate on complete payloads, a Decoder does not apply the migrations given by
+// WithMigration or RegisterMigration: Decode reports a map written with a schema version different from that of the destination as
+// ErrUnsupportedVersion; use UnmarshalBinary to read it.
+type Decoder[K comparable, V comparable] struct {
+	r         *bufio.Reader
+	remaining uint64
+	schema    uint64
+	inMap     bool
+}
+
+// NewDecoder returns a new decoder that reads from r. The decoder may read more data from r than it needs.
+func NewDecoder[K, V comparable](r io.Reader) *Decoder[K, V] {
+	return &Decoder[K, V]{r: bufio.NewReader(r)}
+}
+
+// readHeader reads the header of the next map in the stream.
+func (d *Decoder[K, V]) readHeader() error {
+	var header [len(binaryMagic) + 1]byte
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return decodeError(err)
+	}
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return fmt.Errorf("%w: missing header", ErrInvalidFormat)
+	}
+	d.schema = 0
+	switch version := header[len(binaryMagic)]; version {
+	case 1:
+	case BinaryVersion:
+		schema, err := binary.ReadUvarint(d.r)
+		if err != nil {
+			return decodeError(err)
+		}
+		d.schema = schema
+	default:
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return decodeError(err)
+	}
+	d.remaining, d.inMap = n, true
+	return nil
+}
+
+// Next reads the next entry of the current map from the stream without storing it anywhere. It returns false
+// and a nil error after the last entry of the map, after which Next continues with the following map in the
+// stream, if any. At the end of the stream, Next returns io.EOF.
+func (d *Decoder[K, V]) Next() (key K, value V, ok bool, err error) {
+	if !d.inMap {
+		if err = d.readHeader(); err != nil {
+			return key, value, false, err
+		}
+	}
+	if d.remaining == 0 {
+		d.inMap = false
+		return key, value, false, nil
+	}
+	if err = readValue(d.r, reflect.ValueOf(&key).Elem()); err != nil {
+		return key, value, false, decodeError(err)
+	}
+	if err = readValue(d.r, reflect.ValueOf(&value).Elem()); err != nil {
+		return key, value, false, decodeError(err)
+	}
+	d.remaining--
+	return key, value, true, nil
+}
+
+// Decode reads the next map from the stream and replaces the contents of m with it. Duplicate keys or values
+// result in an error wrapping ErrDuplicateKey or ErrDuplicateValue, and m is left unchanged on error. At the
+// end of the stream, Decode returns io.EOF.
+func (d *Decoder[K, V]) Decode(m *Map[K, V]) error {
+	if !d.inMap {
+		if err := d.readHeader(); err != nil {
+			return err
+		}
+	}
+	if d.schema != m.schemaVersion {
+		return fmt.Errorf("%w: schema version %d, want %d", ErrUnsupportedVersion, d.schema, m.schemaVersion)
+	}
+	kv := make(map[K]V, min(d.remaining, 1<<16))
+	vk := make(map[V]K, min(d.remaining, 1<<16))
+	for {
+		k, v, ok, err := d.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if _, found := kv[k]; found {
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, k)
+		}
+		if k2, found := vk[v]; found {
+			return fmt.Errorf("%w: %v is stored for keys %v and %v", ErrDuplicateValue, v, k2, k)
+		}
+		kv[k] = v
+		vk[v] = k
+	}
+	m.replace(kv, vk)
+	return nil
+}
@@ -0,0 +1,225 @@
+package doublemap
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ErrModified is returned, or used as panic value, by traversals of a map that detect that the map was
+// modified during the traversal.
+var ErrModified = errors.New("doublemap: map modified during traversal")
+
+// checkGen panics with ErrModified if the map was modified since its generation was gen.
+func (m *Map[K, V]) checkGen(gen uint64) {
+	if m.gen != gen {
+		panic(ErrModified)
+	}
+}
+
+// WalkE traverses key-value pairs in the map and provides them to the given function in unspecified order
+// until the function returns an error, which is then returned by WalkE. If fn never fails, WalkE returns nil.
+// If fn modifies the map, WalkE stops and returns ErrModified.
+func (m *Map[K, V]) WalkE(fn func(key K, value V) error) error {
+	gen := m.gen
+	for k, v := range m.kv {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+		if m.gen != gen {
+			return ErrModified
+		}
+	}
+	return nil
+}
+
+// WalkCtx traverses key-value pairs in the map like Walk, but checks ctx before every pair and aborts the
+// traversal with ctx.Err() once the context is done. It returns nil if the traversal was completed or stopped
+// by fn returning false. If fn modifies the map, WalkCtx stops and returns ErrModified.
+func (m *Map[K, V]) WalkCtx(ctx context.Context, fn func(key K, value V) bool) error {
+	gen := m.gen
+	for k, v := range m.kv {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(k, v) {
+			break
+		}
+		if m.gen != gen {
+			return ErrModified
+		}
+	}
+	return nil
+}
+
+// WalkSorted traverses key-value pairs in the map in the key order defined by less and provides them to the
+// given function until the function returns false. The keys are sorted before the traversal starts, which
+// takes O(n log n) time and a slice of all keys. Like Walk, WalkSorted panics with ErrModified if fn modifies
+// the map.
+func (m *Map[K, V]) WalkSorted(less func(a, b K) bool, fn func(key K, value V) bool) {
+	keys := m.Keys()
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	gen := m.gen
+	for _, k := range keys {
+		if !fn(k, m.kv[k]) {
+			break
+		}
+		m.checkGen(gen)
+	}
+}
+
+// WalkOrdered is WalkSorted for maps whose keys are ordered, visiting the pairs in ascending key order.
+func WalkOrdered[K cmp.Ordered, V comparable](m *Map[K, V], fn func(key K, value V) bool) {
+	m.WalkSorted(cmp.Less[K], fn)
+}
+
+// WalkByValue traverses value-key pairs in the reverse index of the map and provides them to the given
+// function in unspecified order until the function returns false. Like Walk, WalkByValue panics with
+// ErrModified if fn modifies the map.
+func (m *Map[K, V]) WalkByValue(fn func(value V, key K) bool) {
+	gen := m.gen
+	for v, k := range m.vk {
+		if !fn(v, k) {
+			break
+		}
+		m.checkGen(gen)
+	}
+}
+
+// WalkChunks traverses the key-value pairs of the map in unspecified order and provides them to the given
+// function in chunks of size pairs until the function returns false. The last chunk may be shorter. Every
+// chunk is a newly allocated slice, so fn may keep it after returning. WalkChunks panics if size is not
+// positive, and like Walk, it panics with ErrModified if fn modifies the map.
+func (m *Map[K, V]) WalkChunks(size int, fn func(chunk []Pair[K, V]) bool) {
+	if size <= 0 {
+		panic("doublemap: chunk size must be positive")
+	}
+	gen := m.gen
+	chunk := make([]Pair[K, V], 0, min(size, len(m.kv)))
+	for k, v := range m.kv {
+		chunk = append(chunk, Pair[K, V]{Key: k, Value: v})
+		if len(chunk) == size {
+			if !fn(chunk) {
+				return
+			}
+			m.checkGen(gen)
+			chunk = make([]Pair[K, V], 0, size)
+		}
+	}
+	if len(chunk) > 0 {
+		fn(chunk)
+	}
+}
+
+// Mutations collects changes requested during WalkMutable, which applies them once the traversal is over.
+type Mutations[K comparable, V comparable] struct {
+	ops []mutation[K, V]
+}
+
+type mutationKind int
+
+const (
+	mutationSet mutationKind = iota
+	mutationRemove
+	mutationRemoveByValue
+)
+
+type mutation[K comparable, V comparable] struct {
+	kind  mutationKind
+	key   K
+	value V
+}
+
+// Set requests that value is set for key after the traversal.
+func (mu *Mutations[K, V]) Set(key K, value V) {
+	mu.ops = append(mu.ops, mutation[K, V]{kind: mutationSet, key: key, value: value})
+}
+
+// Remove requests that the mapping for key is removed after the traversal.
+func (mu *Mutations[K, V]) Remove(key K) {
+	mu.ops = append(mu.ops, mutation[K, V]{kind: mutationRemove, key: key})
+}
+
+// RemoveByValue requests that the mapping for value is removed after the traversal.
+func (mu *Mutations[K, V]) RemoveByValue(value V) {
+	mu.ops = append(mu.ops, mutation[K, V]{kind: mutationRemoveByValue, value: value})
+}
+
+// WalkMutable traverses key-value pairs in the map like Walk, but additionally provides a Mutations value to
+// the given function through which it can request Set, Remove and RemoveByValue operations. The callback sees
+// the map unchanged for the whole traversal, and the requested operations are applied in the order given after
+// the traversal has ended, including when fn stopped it by returning false. For example, the following
+// removes all entries with a negative value:
+//
+//	m.WalkMutable(func(k string, v int, mu *doublemap.Mutations[string, int]) bool {
+//	    if v < 0 {
+//	        mu.Remove(k)
+//	    }
+//	    return true
+//	})
+//
+// Modifying the map directly from fn makes WalkMutable panic with ErrModified, just like Walk.
+func (m *Map[K, V]) WalkMutable(fn func(key K, value V, mu *Mutations[K, V]) bool) {
+	var mu Mutations[K, V]
+	gen := m.gen
+	for k, v := range m.kv {
+		if !fn(k, v, &mu) {
+			break
+		}
+		m.checkGen(gen)
+	}
+	for _, op := range mu.ops {
+		switch op.kind {
+		case mutationSet:
+			m.Set(op.key, op.value)
+		case mutationRemove:
+			m.Remove(op.key)
+		case mutationRemoveByValue:
+			m.RemoveByValue(op.value)
+		}
+	}
+}
+
+// WalkParallel calls fn for every key-value pair in the map using a pool of the given number of worker
+// goroutines, or runtime.GOMAXPROCS(0) workers if workers is zero or less. It is meant for expensive per-entry
+// work such as validating every mapping against a database. All pairs are processed even if fn fails, and the
+// errors returned by fn are combined with errors.Join, in unspecified order, so WalkParallel returns nil only
+// if every call succeeded. Since fn runs concurrently, it must be safe for concurrent use and must not modify
+// the map; if the map was modified nonetheless, the result includes ErrModified.
+func (m *Map[K, V]) WalkParallel(workers int, fn func(key K, value V) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	gen := m.gen
+	pairs := make(chan Pair[K, V], workers)
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		errs  []error
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range pairs {
+				if err := fn(p.Key, p.Value); err != nil {
+					mutex.Lock()
+					errs = append(errs, err)
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for k, v := range m.kv {
+		pairs <- Pair[K, V]{Key: k, Value: v}
+	}
+	close(pairs)
+	wg.Wait()
+	if m.gen != gen {
+		errs = append(errs, ErrModified)
+	}
+	return errors.Join(errs...)
+}
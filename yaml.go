@@ -0,0 +1,70 @@
+//go:build doublemap_yaml
+
+package doublemap
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// This file is only built with the doublemap_yaml build tag, so that the package does not depend on
+// gopkg.in/yaml.v3 unless YAML support is wanted.
+
+// MarshalYAML implements yaml.Marshaler. The map is encoded as an ordinary YAML mapping from keys to values.
+// The keys are sorted by yaml.v3, unless the map was created with WithSortedEncoding or WithKeyOrder, in which
+// case they are written in that order.
+func (m *Map[K, V]) MarshalYAML() (any, error) {
+	if m.kv == nil {
+		return map[K]V{}, nil
+	}
+	if m.keyOrder == nil {
+		return m.kv, nil
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, k := range m.encodingKeys() {
+		var kn, vn yaml.Node
+		if err := kn.Encode(k); err != nil {
+			return nil, err
+		}
+		if err := vn.Encode(m.kv[k]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &kn, &vn)
+	}
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. It replaces the contents of the map with the decoded YAML mapping
+// and rebuilds the reverse index. Duplicate keys or values result in an error wrapping ErrDuplicateKey or
+// ErrDuplicateValue that names the offending line, and the map is left unchanged.
+func (m *Map[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return m.assign(nil)
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("doublemap: line %d: cannot decode YAML %s into a map", node.Line, node.ShortTag())
+	}
+	kv := make(map[K]V, len(node.Content)/2)
+	vk := make(map[V]K, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var k K
+		var v V
+		if err := node.Content[i].Decode(&k); err != nil {
+			return err
+		}
+		if err := node.Content[i+1].Decode(&v); err != nil {
+			return err
+		}
+		if _, ok := kv[k]; ok {
+			return fmt.Errorf("%w: %v at line %d", ErrDuplicateKey, k, node.Content[i].Line)
+		}
+		if k2, ok := vk[v]; ok {
+			return fmt.Errorf("%w: %v at line %d is already stored for key %v", ErrDuplicateValue, v,
+				node.Content[i+1].Line, k2)
+		}
+		kv[k] = v
+		vk[v] = k
+	}
+	return m.assign(kv)
+}